@@ -1,19 +1,30 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"database/sql"
-	"log"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // встроенная база часовых поясов для образов без tzdata
 
+	"final-project/internal/clock"
 	"final-project/internal/config"
 	"final-project/internal/database"
+	"final-project/internal/https"
+	"final-project/internal/logger"
+	"final-project/internal/metrics"
 
-	//"final-project/internal/moduls"
+	"final-project/internal/moduls"
 	"final-project/internal/router"
+	"final-project/internal/tracing"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/v5/middleware"
@@ -22,34 +33,92 @@ import (
 const (
 	defaultPort = "7540"
 	webDir      = "./web"
+
+	// Тайм-ауты HTTP-сервера по умолчанию
+	defaultReadTimeout     = 15 * time.Second
+	defaultWriteTimeout    = 30 * time.Second
+	defaultIdleTimeout     = 60 * time.Second
+	defaultShutdownTimeout = 15 * time.Second
+	// tracingFlushTimeout сколько ждать отправки spans при остановке
+	tracingFlushTimeout = 5 * time.Second
 )
 
 type Server struct {
-	router *chi.Mux
-	db     *sql.DB
-	port   string
+	router  *chi.Mux
+	http    *http.Server
+	db      *sql.DB
+	port    string
+	timeout moduls.HTTP
+	logFile io.Closer
+	// tls настройки HTTPS; nil — сервер работает по HTTP
+	tls    *tls.Config
+	tlsCfg moduls.TLS
+	// redirect перенаправляет запросы по HTTP на HTTPS
+	redirect *http.Server
+	// metrics отдаёт /metrics на отдельном адресе metricsAddr
+	metrics     *http.Server
+	metricsAddr string
+	// stopTracing отправляет накопленные spans
+	stopTracing func(context.Context) error
+	// stopWorkers останавливает фоновые задачи (очистку кэшей)
+	stopWorkers context.CancelFunc
 }
 
 func NewServer() (*Server, error) {
 	// Загрузка конфигурации
-	_, err := config.LoadConfig(".env")
+	cfg, err := config.LoadConfig(".env")
+	if err != nil {
+		return nil, err
+	}
+
+	// Настройка журнала
+	_, logFile, err := logger.Setup(cfg.Log)
+	if err != nil {
+		return nil, err
+	}
+
+	// Настройка трассировки
+	stopTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return nil, err
+	}
+
+	// Сертификат HTTPS
+	tlsConfig, err := https.Config(cfg.TLS)
 	if err != nil {
 		return nil, err
 	}
 
+	// Общий контекст фоновых задач, отменяется при остановке сервера
+	workers, stopWorkers := context.WithCancel(context.Background())
+
 	// Инициализация базы данных
-	db := database.InitDatabase()
+	db := database.InitDatabase(workers)
 	if err := database.TestDatabaseConnection(db.DB); err != nil {
+		stopWorkers()
 		return nil, err
 	}
 
+	// Фиксированное текущее время для воспроизводимых сценариев
+	if cfg.DebugNow != "" {
+		fixed, err := clock.Parse(cfg.DebugNow, cfg.Location)
+		if err != nil {
+			stopWorkers()
+			return nil, fmt.Errorf("неверное значение TODO_DEBUG_NOW: %w", err)
+		}
+		slog.Info("Текущее время зафиксировано", "now", fixed.Format(time.RFC3339))
+		db.SetClock(clock.NewFake(fixed))
+	}
+	if cfg.DBQueryTimeout > 0 {
+		db.SetQueryTimeout(cfg.DBQueryTimeout)
+	}
+
 	// Создание роутера
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 
 	// Настройка маршрутов
-	router.SetupRouter(r, db)
+	router.SetupRouter(workers, r, db, cfg)
 
 	// Получение порта
 	port := os.Getenv("TODO_PORT")
@@ -58,9 +127,18 @@ func NewServer() (*Server, error) {
 	}
 
 	return &Server{
-		router: r,
-		db:     db.DB,
-		port:   port,
+		router:  r,
+		db:      db.DB,
+		port:    port,
+		timeout: cfg.HTTP,
+		logFile: logFile,
+		tls:     tlsConfig,
+		tlsCfg:  cfg.TLS,
+
+		metricsAddr: cfg.MetricsAddr,
+
+		stopTracing: stopTracing,
+		stopWorkers: stopWorkers,
 	}, nil
 }
 
@@ -75,41 +153,147 @@ func (s *Server) Start() error {
 	})
 	s.router.Mount("/", fileServer)
 
+	s.http = &http.Server{
+		Addr:         ":" + s.port,
+		Handler:      https.HSTS(s.tlsCfg)(s.router),
+		TLSConfig:    s.tls,
+		ReadTimeout:  orDefault(s.timeout.ReadTimeout, defaultReadTimeout),
+		WriteTimeout: orDefault(s.timeout.WriteTimeout, defaultWriteTimeout),
+		IdleTimeout:  orDefault(s.timeout.IdleTimeout, defaultIdleTimeout),
+	}
+	if s.tlsCfg.RedirectPort != "" {
+		s.redirect = &http.Server{
+			Addr:         ":" + s.tlsCfg.RedirectPort,
+			Handler:      https.Redirect(s.port),
+			ReadTimeout:  orDefault(s.timeout.ReadTimeout, defaultReadTimeout),
+			WriteTimeout: orDefault(s.timeout.WriteTimeout, defaultWriteTimeout),
+			IdleTimeout:  orDefault(s.timeout.IdleTimeout, defaultIdleTimeout),
+		}
+	}
+
+	if s.metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/metrics", metrics.Handler)
+		s.metrics = &http.Server{
+			Addr:         s.metricsAddr,
+			Handler:      mux,
+			ReadTimeout:  orDefault(s.timeout.ReadTimeout, defaultReadTimeout),
+			WriteTimeout: orDefault(s.timeout.WriteTimeout, defaultWriteTimeout),
+			IdleTimeout:  orDefault(s.timeout.IdleTimeout, defaultIdleTimeout),
+		}
+	}
+
 	// Настройка graceful shutdown
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
 
 	// Запуск сервера
+	serveErr := make(chan error, 3)
 	go func() {
-		log.Printf("Запуск сервера на порту %s", s.port)
-		if err := http.ListenAndServe(":"+s.port, s.router); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Ошибка запуска сервера: %v", err)
+		if s.tls == nil {
+			slog.Info("Запуск сервера", "port", s.port, "scheme", "http")
+			serveErr <- s.http.ListenAndServe()
+			return
 		}
+		// Сертификат уже загружен в TLSConfig
+		slog.Info("Запуск сервера", "port", s.port, "scheme", "https")
+		serveErr <- s.http.ListenAndServeTLS("", "")
 	}()
+	if s.redirect != nil {
+		go func() {
+			slog.Info("Перенаправление с HTTP на HTTPS", "port", s.tlsCfg.RedirectPort)
+			serveErr <- s.redirect.ListenAndServe()
+		}()
+	}
+	if s.metrics != nil {
+		go func() {
+			slog.Info("Метрики на отдельном адресе", "addr", s.metricsAddr)
+			serveErr <- s.metrics.ListenAndServe()
+		}()
+	}
 
 	// Ожидание сигнала для graceful shutdown
-	<-stop
-	log.Println("Получен сигнал завершения работы")
-	return s.Shutdown()
+	select {
+	case err := <-serveErr:
+		// Сервер не запустился, например, порт занят
+		s.Shutdown(context.Background())
+		return fmt.Errorf("ошибка запуска сервера: %w", err)
+	case sig := <-stop:
+		slog.Info("Получен сигнал завершения работы", "signal", sig.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), orDefault(s.timeout.ShutdownTimeout, defaultShutdownTimeout))
+	defer cancel()
+	return s.Shutdown(ctx)
 }
 
-// Закрытие базы данных
-func (s *Server) Shutdown() error {
+// Shutdown останавливает сервер: закрывает перенаправление с HTTP, перестаёт
+// принимать соединения и ждёт завершения начатых запросов, пока не истечёт ctx;
+// оставшиеся соединения обрываются. Затем останавливаются фоновые задачи, отправляются spans
+// и закрывается база данных. Журнал закрывается последним.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var errs []error
+	// Перенаправление не выполняет запросов к API, его можно не ждать
+	if s.redirect != nil {
+		s.redirect.Close()
+	}
+	if s.http != nil {
+		if err := s.http.Shutdown(ctx); err != nil {
+			slog.Warn("Не все запросы завершились до остановки сервера", "error", err)
+			s.http.Close()
+			errs = append(errs, err)
+		}
+	}
+	// Сбор метрик обращается к базе, поэтому завершается до её закрытия
+	if s.metrics != nil {
+		if err := s.metrics.Shutdown(ctx); err != nil {
+			s.metrics.Close()
+		}
+	}
+	if s.stopWorkers != nil {
+		s.stopWorkers()
+	}
+	if s.stopTracing != nil {
+		flushCtx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		if err := s.stopTracing(flushCtx); err != nil {
+			slog.Error("Ошибка отправки трасс", "error", err)
+		}
+		cancel()
+	}
+	// База данных закрывается после всех, кто может к ней обращаться
 	if s.db != nil {
-		s.db.Close()
+		if err := s.db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("ошибка закрытия базы данных: %w", err))
+		}
+	}
+	slog.Info("Сервер остановлен")
+	// Журнал закрывается последним, чтобы сохранить записи о завершении
+	if s.logFile != nil {
+		s.logFile.Close()
+	}
+	return errors.Join(errs...)
+}
+
+// orDefault возвращает value или def, если value не задано
+func orDefault(value, def time.Duration) time.Duration {
+	if value <= 0 {
+		return def
 	}
-	return nil
+	return value
 }
 
 // Основная функция
 func main() {
 	server, err := NewServer()
 	if err != nil {
-		log.Fatalf("Ошибка инициализации сервера: %v", err)
+		slog.Error("Ошибка инициализации сервера", "error", err)
+		os.Exit(1)
 	}
 
 	// Запуск сервера
 	if err := server.Start(); err != nil {
-		log.Fatalf("Ошибка работы сервера: %v", err)
+		slog.Error("Ошибка работы сервера", "error", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,167 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"final-project/internal/logger"
+)
+
+// Code машиночитаемый код ошибки. Коды стабильны: клиенты могут
+// полагаться на них вместо текста сообщения.
+type Code string
+
+const (
+	CodeBadRequest            Code = "bad_request"
+	CodeInvalidJSON           Code = "invalid_json"
+	CodeInvalidCalendar       Code = "invalid_calendar"
+	CodeValidation            Code = "validation_failed"
+	CodeUnauthorized          Code = "unauthorized"
+	CodeNotFound              Code = "not_found"
+	CodeTaskNotFound          Code = "task_not_found"
+	CodeDependencyNotFound    Code = "dependency_not_found"
+	CodeExceptionNotFound     Code = "exception_not_found"
+	CodeCalendarNotFound      Code = "calendar_not_found"
+	CodeMethodNotAllowed      Code = "method_not_allowed"
+	CodeTaskBlocked           Code = "task_blocked"
+	CodeTaskNotRecurring      Code = "task_not_recurring"
+	CodeSelfDependency        Code = "self_dependency"
+	CodeDependencyCycle       Code = "dependency_cycle"
+	CodeIdempotencyKeyReused  Code = "idempotency_key_reused"
+	CodeIdempotencyInProgress Code = "idempotency_in_progress"
+	CodeRequestTooLarge       Code = "request_too_large"
+	CodeRateLimited           Code = "rate_limited"
+	CodeInternal              Code = "internal_error"
+	CodeServiceUnavailable    Code = "service_unavailable"
+	CodeTimeout               Code = "timeout"
+)
+
+// FieldCode код ошибки отдельного поля или параметра запроса
+type FieldCode string
+
+const (
+	FieldRequired       FieldCode = "required"
+	FieldInvalidFormat  FieldCode = "invalid_format"
+	FieldInvalidValue   FieldCode = "invalid_value"
+	FieldOutOfRange     FieldCode = "out_of_range"
+	FieldConflict       FieldCode = "conflict"
+	FieldRequiresRepeat FieldCode = "requires_repeat"
+	FieldUnsupported    FieldCode = "unsupported"
+)
+
+// FieldError ошибка проверки одного поля
+type FieldError struct {
+	Field   string    `json:"field"`
+	Code    FieldCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// Field создаёт ошибку поля; сообщение подставляется при отправке ответа
+func Field(field string, code FieldCode) FieldError {
+	return FieldError{Field: field, Code: code}
+}
+
+// Error ошибка API: HTTP-статус, код, ошибки полей и дополнительные данные ответа
+type Error struct {
+	Status int
+	Code   Code
+	Fields []FieldError
+	// Extra дополнительные поля ответа, например список блокирующих задач
+	Extra map[string]interface{}
+	// Cause исходная ошибка; пишется только в лог
+	Cause error
+}
+
+// New создаёт ошибку с указанным статусом и кодом
+func New(status int, code Code) *Error {
+	return &Error{Status: status, Code: code}
+}
+
+// Validation создаёт ошибку проверки данных со статусом 422
+func Validation(fields ...FieldError) *Error {
+	return &Error{Status: http.StatusUnprocessableEntity, Code: CodeValidation, Fields: fields}
+}
+
+// Invalid создаёт ошибку проверки одного поля
+func Invalid(field string, code FieldCode) *Error {
+	return Validation(Field(field, code))
+}
+
+// Internal создаёт внутреннюю ошибку сервера; причина пишется в лог
+func Internal(cause error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Cause: cause}
+}
+
+// With добавляет в ответ дополнительное поле
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Extra == nil {
+		e.Extra = make(map[string]interface{})
+	}
+	e.Extra[key] = value
+	return e
+}
+
+// Wrap сохраняет исходную ошибку для лога
+func (e *Error) Wrap(cause error) *Error {
+	e.Cause = cause
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return string(e.Code) + ": " + e.Cause.Error()
+	}
+	return string(e.Code)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Write отправляет ошибку клиенту. Сообщения переводятся на язык
+// из заголовка Accept-Language. Ошибки других типов считаются внутренними.
+//
+// Тело ответа: {"error": "...", "code": "...", "details": [...]},
+// details присутствует только при ошибках полей.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		apiErr = Internal(err)
+	}
+
+	lang := Language(r)
+	log := logger.FromContext(r.Context())
+	// Ошибки клиента — обычные события, ошибки сервера требуют внимания
+	attrs := []any{"code", apiErr.Code, "status", apiErr.Status}
+	if apiErr.Cause != nil {
+		attrs = append(attrs, "error", apiErr.Cause)
+	}
+	if apiErr.Status >= http.StatusInternalServerError {
+		log.Error("Отправка ошибки", attrs...)
+	} else {
+		log.Info("Отправка ошибки", attrs...)
+	}
+
+	body := make(map[string]interface{}, len(apiErr.Extra)+3)
+	for key, value := range apiErr.Extra {
+		body[key] = value
+	}
+	body["error"] = Message(lang, apiErr.Code)
+	body["code"] = apiErr.Code
+	if len(apiErr.Fields) > 0 {
+		details := make([]FieldError, len(apiErr.Fields))
+		for i, f := range apiErr.Fields {
+			f.Message = FieldMessage(lang, f.Code)
+			details[i] = f
+		}
+		body["details"] = details
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", lang)
+	w.WriteHeader(apiErr.Status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Error("Ошибка кодирования ответа с ошибкой", "error", err)
+	}
+}
@@ -0,0 +1,138 @@
+package apierror
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage язык сообщений, если клиент не указал поддерживаемый
+const DefaultLanguage = "en"
+
+// messages тексты ошибок по языкам
+var messages = map[string]map[Code]string{
+	"en": {
+		CodeBadRequest:            "Invalid request",
+		CodeInvalidJSON:           "Request body is not valid JSON",
+		CodeInvalidCalendar:       "Calendar is not valid iCalendar data",
+		CodeValidation:            "Request validation failed",
+		CodeUnauthorized:          "Authorization required",
+		CodeNotFound:              "Resource not found",
+		CodeTaskNotFound:          "Task not found",
+		CodeDependencyNotFound:    "Dependency not found",
+		CodeExceptionNotFound:     "Exception date not found",
+		CodeCalendarNotFound:      "Calendar not found",
+		CodeMethodNotAllowed:      "Method not allowed",
+		CodeTaskBlocked:           "Task is blocked by unfinished tasks",
+		CodeTaskNotRecurring:      "Task is not recurring",
+		CodeSelfDependency:        "Task cannot depend on itself",
+		CodeDependencyCycle:       "Dependency would create a cycle",
+		CodeIdempotencyKeyReused:  "Idempotency key was already used with a different request",
+		CodeIdempotencyInProgress: "A request with this idempotency key is still in progress",
+		CodeRequestTooLarge:       "Request body is too large",
+		CodeRateLimited:           "Too many requests, retry later",
+		CodeInternal:              "Internal server error",
+		CodeServiceUnavailable:    "Service unavailable",
+		CodeTimeout:               "Request timed out",
+	},
+	"ru": {
+		CodeBadRequest:            "Неверный запрос",
+		CodeInvalidJSON:           "Тело запроса не является корректным JSON",
+		CodeInvalidCalendar:       "Календарь не соответствует формату iCalendar",
+		CodeValidation:            "Ошибка проверки данных",
+		CodeUnauthorized:          "Требуется авторизация",
+		CodeNotFound:              "Ресурс не найден",
+		CodeTaskNotFound:          "Задача не найдена",
+		CodeDependencyNotFound:    "Зависимость не найдена",
+		CodeExceptionNotFound:     "Дата-исключение не найдена",
+		CodeCalendarNotFound:      "Календарь не найден",
+		CodeMethodNotAllowed:      "Метод не поддерживается",
+		CodeTaskBlocked:           "Задача заблокирована невыполненными задачами",
+		CodeTaskNotRecurring:      "Задача не повторяется",
+		CodeSelfDependency:        "Задача не может зависеть от самой себя",
+		CodeDependencyCycle:       "Зависимость образует цикл",
+		CodeIdempotencyKeyReused:  "Ключ идемпотентности уже использован с другим запросом",
+		CodeIdempotencyInProgress: "Запрос с этим ключом идемпотентности ещё выполняется",
+		CodeRequestTooLarge:       "Тело запроса слишком большое",
+		CodeRateLimited:           "Слишком много запросов, повторите позже",
+		CodeInternal:              "Внутренняя ошибка сервера",
+		CodeServiceUnavailable:    "Сервис недоступен",
+		CodeTimeout:               "Превышено время ожидания запроса",
+	},
+}
+
+// fieldMessages тексты ошибок полей по языкам
+var fieldMessages = map[string]map[FieldCode]string{
+	"en": {
+		FieldRequired:       "is required",
+		FieldInvalidFormat:  "has an invalid format",
+		FieldInvalidValue:   "has an invalid value",
+		FieldOutOfRange:     "is out of range",
+		FieldConflict:       "cannot be combined with another parameter",
+		FieldRequiresRepeat: "requires a repeat rule",
+		FieldUnsupported:    "is not supported",
+	},
+	"ru": {
+		FieldRequired:       "обязательное поле",
+		FieldInvalidFormat:  "неверный формат",
+		FieldInvalidValue:   "недопустимое значение",
+		FieldOutOfRange:     "значение вне допустимого диапазона",
+		FieldConflict:       "нельзя указывать вместе с другим параметром",
+		FieldRequiresRepeat: "требует правила повтора",
+		FieldUnsupported:    "не поддерживается",
+	},
+}
+
+// Message возвращает текст ошибки на языке lang
+func Message(lang string, code Code) string {
+	if msg, ok := messages[lang][code]; ok {
+		return msg
+	}
+	if msg, ok := messages[DefaultLanguage][code]; ok {
+		return msg
+	}
+	return string(code)
+}
+
+// FieldMessage возвращает текст ошибки поля на языке lang
+func FieldMessage(lang string, code FieldCode) string {
+	if msg, ok := fieldMessages[lang][code]; ok {
+		return msg
+	}
+	if msg, ok := fieldMessages[DefaultLanguage][code]; ok {
+		return msg
+	}
+	return string(code)
+}
+
+// Language выбирает язык сообщений по заголовку Accept-Language
+// с учётом весов q, например "ru-RU,ru;q=0.9,en;q=0.8"
+func Language(r *http.Request) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		// Язык без региона: ru-RU -> ru
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := messages[lang]; ok && q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
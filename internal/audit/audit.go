@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// Действия, записываемые в журнал аудита
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionDone   = "done"
+	ActionSkip   = "skip"
+	ActionSnooze = "snooze"
+)
+
+// Anonymous автор изменений в запросах без токена
+const Anonymous = "anonymous"
+
+// Source автор изменения и запрос, в котором оно сделано
+type Source struct {
+	Actor     string
+	RequestID string
+}
+
+// Ключи контекста, чтобы избежать пересечений с другими пакетами
+type (
+	sourceKey struct{}
+	actionKey struct{}
+)
+
+// WithSource сохраняет в контексте автора изменений и ID запроса
+func WithSource(ctx context.Context, s Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, s)
+}
+
+// FromContext возвращает автора изменений и ID запроса из контекста.
+// Изменения вне HTTP-запроса записываются от имени Anonymous.
+func FromContext(ctx context.Context) Source {
+	if s, ok := ctx.Value(sourceKey{}).(Source); ok {
+		return s
+	}
+	return Source{Actor: Anonymous}
+}
+
+// WithAction задаёт действие, которым будут записаны изменения в контексте ctx,
+// вместо create, update или delete. Например, выполнение задачи удаляет или
+// переносит её, но в журнале записывается как done.
+func WithAction(ctx context.Context, action string) context.Context {
+	return context.WithValue(ctx, actionKey{}, action)
+}
+
+// Action возвращает действие из контекста или def, если оно не задано
+func Action(ctx context.Context, def string) string {
+	if action, ok := ctx.Value(actionKey{}).(string); ok {
+		return action
+	}
+	return def
+}
+
+// Middleware сохраняет в контексте запроса автора изменений и X-Request-ID.
+// Пока токены не проверяются, автор — первые 16 символов SHA-256 токена
+// из заголовка Authorization: сам токен в базу не попадает.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := Source{
+			Actor:     Actor(r),
+			RequestID: r.Header.Get("X-Request-ID"),
+		}
+		next.ServeHTTP(w, r.WithContext(WithSource(r.Context(), s)))
+	})
+}
+
+// Actor возвращает автора изменений для запроса r
+func Actor(r *http.Request) string {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		return Anonymous
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:8])
+}
@@ -1,23 +1,31 @@
 package auth
 
 import (
-	"bytes"
-	"encoding/json"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
+
+	"final-project/internal/apierror"
+	"final-project/internal/logger"
+	"final-project/internal/metrics"
+	"final-project/internal/moduls"
+	"final-project/internal/tracing"
 )
 
 // Структура для записи ответа
 type responseWriter struct {
 	http.ResponseWriter
-	body       *bytes.Buffer
+	body       io.Writer // копия тела ответа; nil, если тело не записывается
 	statusCode int
 }
 
 // Write перехватывает запись ответа
 func (w *responseWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	if w.body != nil {
+		w.body.Write(b)
+	}
 	return w.ResponseWriter.Write(b)
 }
 
@@ -27,53 +35,79 @@ func (w *responseWriter) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
-// Структура для логирования запроса
-type RequestLog struct {
-	Method     string        `json:"method"`
-	Path       string        `json:"path"`
-	Status     int           `json:"status"`
-	Duration   time.Duration `json:"duration"`
-	RequestID  string        `json:"request_id"`
-	UserAgent  string        `json:"user_agent"`
-	RemoteAddr string        `json:"remote_addr"`
-	Body       interface{}   `json:"body,omitempty"`
+// LoggingMiddleware записывает каждый запрос в журнал и передаёт обработчикам
+// журнал запроса с его X-Request-ID (logger.FromContext). Тела запросов
+// и ответов записываются, только если это включено в настройках cfg.
+func LoggingMiddleware(cfg moduls.BodyCapture) func(http.Handler) http.Handler {
+	bodies := logger.NewBodyCapture(cfg)
+	return func(next http.Handler) http.Handler {
+		return loggingHandler(next, bodies)
+	}
 }
 
-// Логирование запросов
-func LoggingMiddleware(next http.Handler) http.Handler {
+func loggingHandler(next http.Handler, bodies *logger.BodyCapture) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		// Создаем буфер для тела ответа
 		lrw := &responseWriter{
 			ResponseWriter: w,
-			body:           &bytes.Buffer{},
+			statusCode:     http.StatusOK,
+		}
+		// Копии тел создаются только для запросов, попавших в выборку
+		capture := bodies.Start(r)
+		if capture != nil {
+			lrw.body = capture.Response()
 		}
 
-		// Добавляем request_id в заголовки
+		// Добавляем request_id в заголовки запроса и ответа
 		requestID := r.Header.Get("X-Request-ID")
 		if requestID == "" {
 			requestID = generateRequestID()
 		}
 		r.Header.Set("X-Request-ID", requestID)
+		w.Header().Set("X-Request-ID", requestID)
+
+		// Span запроса продолжает трассу вызывающего сервиса из traceparent
+		ctx, span := tracing.StartRequest(r)
+
+		// Журнал запроса: все записи обработчиков получают request_id и trace_id
+		reqLogger := slog.Default().With("request_id", requestID)
+		if sc := span.SpanContext(); sc.IsValid() {
+			reqLogger = reqLogger.With("trace_id", sc.TraceID().String())
+		}
+		r = r.WithContext(logger.WithContext(ctx, reqLogger))
 
 		// Обрабатываем запрос
 		next.ServeHTTP(lrw, r)
-
-		// Формируем лог
-		reqLog := RequestLog{
-			Method:     r.Method,
-			Path:       r.URL.Path,
-			Status:     lrw.statusCode,
-			Duration:   time.Since(start),
-			RequestID:  requestID,
-			UserAgent:  r.UserAgent(),
-			RemoteAddr: r.RemoteAddr,
+		route := metrics.Route(r)
+		tracing.EndRequest(span, r.Method, route, lrw.statusCode)
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", route),
+			slog.Int("status", lrw.statusCode),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("user_agent", r.UserAgent()),
+		}
+		// Заголовки пишутся только при отладке; Authorization и Cookie скрываются
+		if reqLogger.Enabled(r.Context(), slog.LevelDebug) {
+			headers := make([]any, 0, len(r.Header))
+			for name, values := range r.Header {
+				headers = append(headers, slog.String(name, strings.Join(values, ", ")))
+			}
+			attrs = append(attrs, slog.Group("headers", headers...))
+		}
+		if capture != nil {
+			attrs = append(attrs, capture.Attrs(r, lrw.Header())...)
 		}
 
-		// Логируем запрос
-		logJSON, _ := json.Marshal(reqLog)
-		log.Printf("Request: %s", string(logJSON))
+		level := slog.LevelInfo
+		if lrw.statusCode >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		reqLogger.LogAttrs(r.Context(), level, "Запрос обработан", attrs...)
 	})
 }
 
@@ -104,7 +138,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		// Проверяем токен
 		token := r.Header.Get("Authorization")
 		if token == "" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized))
 			return
 		}
 
@@ -122,6 +156,10 @@ func AuthMiddleware(next http.Handler) http.Handler {
 func isPublicEndpoint(path string) bool {
 	publicPaths := []string{
 		"/api/health",
+		"/api/health/live",
+		"/api/health/ready",
+		"/api/openapi.json",
+		"/api/docs",
 		"/api/login",
 		"/api/register",
 	}
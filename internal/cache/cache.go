@@ -1,8 +1,12 @@
 package cache
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"final-project/internal/metrics"
 )
 
 // CacheItem представляет элемент кэша
@@ -13,19 +17,62 @@ type CacheItem struct {
 
 // Cache представляет структуру кэша
 type Cache struct {
-	items map[string]CacheItem
-	mu    sync.RWMutex
+	name    string // имя кэша в метриках
+	items   map[string]CacheItem
+	mu      sync.RWMutex
+	cleanup atomic.Bool // работает ли фоновая очистка
+}
+
+// Stats состояние кэша для диагностики
+type Stats struct {
+	Name  string `json:"name"`
+	Items int    `json:"items"`
+	// Cleanup работает ли фоновая очистка устаревших элементов
+	Cleanup bool `json:"cleanup"`
 }
 
-// NewCache создает новый экземпляр кэша
-func NewCache() *Cache {
+// Все созданные кэши, чтобы показывать их состояние в диагностике
+var (
+	registryMu sync.Mutex
+	registry   []*Cache
+)
+
+// NewCache создает новый экземпляр кэша; name — имя кэша в метриках.
+// Очистка устаревших элементов работает, пока не отменён ctx.
+func NewCache(ctx context.Context, name string) *Cache {
 	cache := &Cache{
+		name:  name,
 		items: make(map[string]CacheItem),
 	}
-	go cache.cleanupLoop() // запуск очистки кэша
+	cache.cleanup.Store(true)
+	go cache.cleanupLoop(ctx) // запуск очистки кэша
+
+	registryMu.Lock()
+	registry = append(registry, cache)
+	registryMu.Unlock()
 	return cache
 }
 
+// All возвращает состояние всех созданных кэшей
+func All() []Stats {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	stats := make([]Stats, 0, len(registry))
+	for _, c := range registry {
+		stats = append(stats, c.Stats())
+	}
+	return stats
+}
+
+// Stats возвращает число элементов кэша и состояние фоновой очистки
+func (c *Cache) Stats() Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return Stats{Name: c.name, Items: len(c.items), Cleanup: c.cleanup.Load()}
+}
+
 // Set добавляет значение в кэш
 func (c *Cache) Set(key string, value interface{}, duration time.Duration) {
 	c.mu.Lock()
@@ -44,6 +91,29 @@ func (c *Cache) Set(key string, value interface{}, duration time.Duration) {
 	}
 }
 
+// Add добавляет значение, только если ключа нет в кэше или его время истекло.
+// Возвращает false, если значение уже есть.
+func (c *Cache) Add(key string, value interface{}, duration time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item, exists := c.items[key]; exists {
+		if item.Expiration == 0 || time.Now().UnixNano() <= item.Expiration {
+			return false
+		}
+	}
+
+	var expiration int64
+	if duration > 0 {
+		expiration = time.Now().Add(duration).UnixNano()
+	}
+	c.items[key] = CacheItem{
+		Value:      value,
+		Expiration: expiration,
+	}
+	return true
+}
+
 // Get получает значение из кэша
 func (c *Cache) Get(key string) (interface{}, bool) {
 	c.mu.RLock()
@@ -52,14 +122,17 @@ func (c *Cache) Get(key string) (interface{}, bool) {
 	// получение значения из кэша
 	item, exists := c.items[key]
 	if !exists {
+		metrics.CacheMiss(c.name)
 		return nil, false
 	}
 
 	// проверка на истечение времени
 	if item.Expiration > 0 && time.Now().UnixNano() > item.Expiration {
+		metrics.CacheMiss(c.name)
 		return nil, false
 	}
 
+	metrics.CacheHit(c.name)
 	return item.Value, true // возвращает значение и true, если оно существует и не истекло
 }
 
@@ -71,10 +144,25 @@ func (c *Cache) Delete(key string) {
 	delete(c.items, key)
 }
 
-// cleanupLoop очищает устаревшие элементы кэша
-func (c *Cache) cleanupLoop() {
+// Clear удаляет все значения из кэша
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]CacheItem)
+}
+
+// cleanupLoop очищает устаревшие элементы кэша до отмены ctx
+func (c *Cache) cleanupLoop(ctx context.Context) {
 	ticker := time.NewTicker(time.Minute) // установка интервала очистки кэша
-	for range ticker.C {
+	defer ticker.Stop()
+	defer c.cleanup.Store(false)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 		c.mu.Lock()
 		now := time.Now().UnixNano()
 		for key, item := range c.items { // проверка на истечение времени
@@ -0,0 +1,112 @@
+package clock
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"final-project/internal/apierror"
+	"final-project/internal/timezone"
+	"final-project/internal/utils"
+)
+
+// DebugHeader заголовок запроса, подменяющий текущее время в отладочном режиме.
+// Значение — дата в формате utils.DateFormat или время в формате RFC 3339.
+const DebugHeader = "X-Debug-Now"
+
+// Clock источник текущего времени
+type Clock interface {
+	Now() time.Time
+}
+
+// System часы, возвращающие системное время
+type System struct{}
+
+// Now возвращает текущее системное время
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// Fake часы с заданным временем для тестов и воспроизводимых сценариев
+type Fake struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// NewFake создаёт часы, показывающие указанное время
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now возвращает установленное время
+func (f *Fake) Now() time.Time {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.now
+}
+
+// Set устанавливает время
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// Advance сдвигает время на указанную продолжительность
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// contextKey тип ключа контекста, чтобы избежать пересечений с другими пакетами
+type contextKey struct{}
+
+// WithContext сохраняет часы в контексте
+func WithContext(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext возвращает часы из контекста или системные часы
+func FromContext(ctx context.Context) Clock {
+	return FromContextOr(ctx, System{})
+}
+
+// FromContextOr возвращает часы из контекста или def, если в контексте их нет
+func FromContextOr(ctx context.Context, def Clock) Clock {
+	if c, ok := ctx.Value(contextKey{}).(Clock); ok {
+		return c
+	}
+	return def
+}
+
+// Parse разбирает дату в формате utils.DateFormat (полночь в часовом поясе loc)
+// или время в формате RFC 3339
+func Parse(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation(utils.DateFormat, value, loc); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// Middleware сохраняет часы в контексте запроса. Если debug включён, время
+// можно подменить заголовком X-Debug-Now. Должен подключаться после
+// timezone.Middleware, чтобы дата из заголовка понималась в часовом поясе запроса.
+func Middleware(c Clock, debug bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqClock := c
+			if value := r.Header.Get(DebugHeader); debug && value != "" {
+				now, err := Parse(value, timezone.FromContext(r.Context()))
+				if err != nil {
+					apierror.Write(w, r, apierror.Invalid(DebugHeader, apierror.FieldInvalidFormat).Wrap(err))
+					return
+				}
+				reqClock = NewFake(now)
+				w.Header().Set(DebugHeader, now.Format(time.RFC3339))
+			}
+			next.ServeHTTP(w, r.WithContext(WithContext(r.Context(), reqClock)))
+		})
+	}
+}
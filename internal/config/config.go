@@ -3,8 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"final-project/internal/moduls"
+	"final-project/internal/timezone"
 
 	"github.com/joho/godotenv"
 )
@@ -17,8 +21,16 @@ func LoadConfig(filename string) (*moduls.Config, error) {
 	}
 	// Конфигурация
 	config := &moduls.Config{
-		Port:   os.Getenv("TODO_PORT"),
-		DBFile: os.Getenv("TODO_DBFILE"),
+		Port:        os.Getenv("TODO_PORT"),
+		DBFile:      os.Getenv("TODO_DBFILE"),
+		Timezone:    os.Getenv("TODO_TIMEZONE"),
+		DebugNow:    os.Getenv("TODO_DEBUG_NOW"),
+		MetricsAddr: os.Getenv("TODO_METRICS_ADDR"),
+		Log: moduls.Log{
+			Level:  os.Getenv("LOG_LEVEL"),
+			Format: os.Getenv("LOG_FORMAT"),
+			File:   os.Getenv("LOG_FILE"),
+		},
 		// JWTSecret: os.Getenv("TODO_JWT_SECRET"),
 		// Password: os.Getenv("TODO_PASSWORD"),
 	}
@@ -27,5 +39,215 @@ func LoadConfig(filename string) (*moduls.Config, error) {
 	if config.Port == "" || config.DBFile == "" { //|| config.Password == "" {
 		return nil, fmt.Errorf("отсутствуют обязательные переменные окружения")
 	}
+
+	// Часовой пояс экземпляра
+	config.Location, err = timezone.Load(config.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("неверный часовой пояс TODO_TIMEZONE: %w", err)
+	}
+
+	// Отладочная подмена текущего времени
+	if debug := os.Getenv("TODO_DEBUG_CLOCK"); debug != "" {
+		config.DebugClock, err = strconv.ParseBool(debug)
+		if err != nil {
+			return nil, fmt.Errorf("неверное значение TODO_DEBUG_CLOCK: %w", err)
+		}
+	}
+
+	// Время хранения ответов на запросы с Idempotency-Key
+	if err := envDuration("TODO_IDEMPOTENCY_TTL", &config.IdempotencyTTL); err != nil {
+		return nil, err
+	}
+
+	// Время на один вызов базы данных
+	if err := envDuration("TODO_DB_QUERY_TIMEOUT", &config.DBQueryTimeout); err != nil {
+		return nil, err
+	}
+
+	// Тайм-ауты HTTP-сервера и ожидания запросов при остановке
+	if err := envDuration("TODO_READ_TIMEOUT", &config.HTTP.ReadTimeout); err != nil {
+		return nil, err
+	}
+	if err := envDuration("TODO_WRITE_TIMEOUT", &config.HTTP.WriteTimeout); err != nil {
+		return nil, err
+	}
+	if err := envDuration("TODO_IDLE_TIMEOUT", &config.HTTP.IdleTimeout); err != nil {
+		return nil, err
+	}
+	if err := envDuration("TODO_SHUTDOWN_TIMEOUT", &config.HTTP.ShutdownTimeout); err != nil {
+		return nil, err
+	}
+
+	// HTTPS
+	if err := loadTLS(&config.TLS, config.Port); err != nil {
+		return nil, err
+	}
+
+	// Ограничение частоты запросов, по умолчанию включено
+	config.RateLimit.Enabled = true
+	if enabled := os.Getenv("TODO_RATE_LIMIT"); enabled != "" {
+		config.RateLimit.Enabled, err = strconv.ParseBool(enabled)
+		if err != nil {
+			return nil, fmt.Errorf("неверное значение TODO_RATE_LIMIT: %w", err)
+		}
+	}
+	if err := envFloat("TODO_RATE_LIMIT_READ", &config.RateLimit.ReadRate); err != nil {
+		return nil, err
+	}
+	if err := envInt("TODO_RATE_LIMIT_READ_BURST", &config.RateLimit.ReadBurst); err != nil {
+		return nil, err
+	}
+	if err := envFloat("TODO_RATE_LIMIT_WRITE", &config.RateLimit.WriteRate); err != nil {
+		return nil, err
+	}
+	if err := envInt("TODO_RATE_LIMIT_WRITE_BURST", &config.RateLimit.WriteBurst); err != nil {
+		return nil, err
+	}
+
+	// Ротация файла журнала
+	if err := envInt("LOG_MAX_SIZE", &config.Log.MaxSizeMB); err != nil {
+		return nil, err
+	}
+	if err := envInt("LOG_MAX_BACKUPS", &config.Log.MaxBackups); err != nil {
+		return nil, err
+	}
+
+	// Трассировка OpenTelemetry
+	config.Tracing.Exporter = os.Getenv("TODO_TRACING")
+	config.Tracing.Endpoint = os.Getenv("TODO_TRACING_ENDPOINT")
+	config.Tracing.ServiceName = os.Getenv("OTEL_SERVICE_NAME")
+	if err := envFloat("TODO_TRACING_SAMPLE", &config.Tracing.SampleRatio); err != nil {
+		return nil, err
+	}
+	if config.Tracing.SampleRatio > 1 {
+		return nil, fmt.Errorf("неверное значение TODO_TRACING_SAMPLE: %v больше 1", config.Tracing.SampleRatio)
+	}
+
+	// Запись тел запросов и ответов, по умолчанию выключена
+	if enabled := os.Getenv("LOG_BODY"); enabled != "" {
+		config.Log.Body.Enabled, err = strconv.ParseBool(enabled)
+		if err != nil {
+			return nil, fmt.Errorf("неверное значение LOG_BODY: %w", err)
+		}
+	}
+	if err := envFloat("LOG_BODY_SAMPLE", &config.Log.Body.SampleRate); err != nil {
+		return nil, err
+	}
+	if config.Log.Body.SampleRate > 1 {
+		return nil, fmt.Errorf("неверное значение LOG_BODY_SAMPLE: %v больше 1", config.Log.Body.SampleRate)
+	}
+	if err := envInt("LOG_BODY_MAX_BYTES", &config.Log.Body.MaxBytes); err != nil {
+		return nil, err
+	}
+	if config.Log.Body.Routes, err = parseRoutes(os.Getenv("LOG_BODY_ROUTES")); err != nil {
+		return nil, err
+	}
+	for _, field := range strings.Split(os.Getenv("LOG_BODY_MASK"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			config.Log.Body.Mask = append(config.Log.Body.Mask, field)
+		}
+	}
 	return config, nil
 }
+
+// loadTLS читает настройки HTTPS. Сертификат и ключ задаются вместе;
+// HSTS по умолчанию включён только для настоящего сертификата, иначе
+// браузер запомнит хост и не позволит принять самоподписанный сертификат.
+func loadTLS(cfg *moduls.TLS, port string) (err error) {
+	cfg.CertFile = os.Getenv("TODO_TLS_CERT")
+	cfg.KeyFile = os.Getenv("TODO_TLS_KEY")
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return fmt.Errorf("TODO_TLS_CERT и TODO_TLS_KEY задаются вместе")
+	}
+	if selfSigned := os.Getenv("TODO_TLS_SELF_SIGNED"); selfSigned != "" {
+		cfg.SelfSigned, err = strconv.ParseBool(selfSigned)
+		if err != nil {
+			return fmt.Errorf("неверное значение TODO_TLS_SELF_SIGNED: %w", err)
+		}
+	}
+	for _, host := range strings.Split(os.Getenv("TODO_TLS_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			cfg.Hosts = append(cfg.Hosts, host)
+		}
+	}
+	enabled := cfg.CertFile != "" || cfg.SelfSigned
+
+	cfg.RedirectPort = os.Getenv("TODO_TLS_REDIRECT_PORT")
+	if cfg.RedirectPort != "" {
+		if !enabled {
+			return fmt.Errorf("TODO_TLS_REDIRECT_PORT задан, но HTTPS не настроен")
+		}
+		if cfg.RedirectPort == port {
+			return fmt.Errorf("TODO_TLS_REDIRECT_PORT совпадает с TODO_PORT")
+		}
+	}
+
+	cfg.HSTS = enabled && !cfg.SelfSigned
+	if hsts := os.Getenv("TODO_HSTS"); hsts != "" {
+		cfg.HSTS, err = strconv.ParseBool(hsts)
+		if err != nil {
+			return fmt.Errorf("неверное значение TODO_HSTS: %w", err)
+		}
+	}
+	return envDuration("TODO_HSTS_MAX_AGE", &cfg.HSTSMaxAge)
+}
+
+// parseRoutes разбирает список "префикс=доля" через запятую, например
+// "/api/task=1,/api/tasks=0"
+func parseRoutes(value string) (map[string]float64, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	routes := make(map[string]float64)
+	for _, item := range strings.Split(value, ",") {
+		prefix, rate, ok := strings.Cut(strings.TrimSpace(item), "=")
+		r, err := strconv.ParseFloat(rate, 64)
+		if !ok || prefix == "" || err != nil || r < 0 || r > 1 {
+			return nil, fmt.Errorf("неверное значение LOG_BODY_ROUTES: %q", item)
+		}
+		routes[prefix] = r
+	}
+	return routes, nil
+}
+
+// envFloat читает положительное число из переменной окружения, если она задана
+func envFloat(name string, value *float64) error {
+	env := os.Getenv(name)
+	if env == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(env, 64)
+	if err != nil || v <= 0 {
+		return fmt.Errorf("неверное значение %s: %q", name, env)
+	}
+	*value = v
+	return nil
+}
+
+// envInt читает положительное целое из переменной окружения, если она задана
+func envInt(name string, value *int) error {
+	env := os.Getenv(name)
+	if env == "" {
+		return nil
+	}
+	v, err := strconv.Atoi(env)
+	if err != nil || v <= 0 {
+		return fmt.Errorf("неверное значение %s: %q", name, env)
+	}
+	*value = v
+	return nil
+}
+
+// envDuration читает положительную длительность (например, 30s) из переменной окружения, если она задана
+func envDuration(name string, value *time.Duration) error {
+	env := os.Getenv(name)
+	if env == "" {
+		return nil
+	}
+	v, err := time.ParseDuration(env)
+	if err != nil || v <= 0 {
+		return fmt.Errorf("неверное значение %s: %q", name, env)
+	}
+	*value = v
+	return nil
+}
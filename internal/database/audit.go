@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"final-project/internal/audit"
+	moduls "final-project/internal/moduls"
+)
+
+// auditTimeFormat время записи аудита в UTC. Длина строки постоянна,
+// поэтому строки можно сравнивать в SQL вместо времени.
+const auditTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// writeAudit записывает изменение задачи taskID в журнал аудита в той же
+// транзакции, что и само изменение. Действие action используется, если
+// в контексте не задано другое (audit.WithAction), now — время изменения.
+func writeAudit(ctx context.Context, tx *sql.Tx, now time.Time, action string, taskID interface{}, before, after *moduls.Scheduler) error {
+	beforeJSON, err := auditSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditSnapshot(after)
+	if err != nil {
+		return err
+	}
+
+	source := audit.FromContext(ctx)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log (time, actor, request_id, action, task_id, before, after)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, now.UTC().Format(auditTimeFormat), source.Actor, source.RequestID,
+		audit.Action(ctx, action), taskID, beforeJSON, afterJSON)
+	if err != nil {
+		return fmt.Errorf("ошибка записи в журнал аудита: %w", err)
+	}
+	return nil
+}
+
+// auditSnapshot сериализует состояние задачи; отсутствующее состояние хранится как NULL
+func auditSnapshot(task *moduls.Scheduler) (interface{}, error) {
+	if task == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации задачи для аудита: %w", err)
+	}
+	return string(data), nil
+}
+
+// readTaskTx читает задачу внутри транзакции, чтобы получить её состояние до изменения
+func readTaskTx(ctx context.Context, tx *sql.Tx, id interface{}) (*moduls.Scheduler, error) {
+	task, err := scanTask(tx.QueryRowContext(ctx, selectTasks+" WHERE s.id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения задачи: %w", err)
+	}
+	return &task, nil
+}
+
+// AuditLog возвращает записи журнала аудита, подходящие под filter, начиная с последней
+func (db *DB) AuditLog(ctx context.Context, filter moduls.AuditFilter) (_ []moduls.AuditEntry, err error) {
+	var conditions []string
+	var args []interface{}
+	if filter.TaskID != "" {
+		conditions = append(conditions, "task_id = ?")
+		args = append(args, filter.TaskID)
+	}
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "time >= ?")
+		args = append(args, filter.From.UTC().Format(auditTimeFormat))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "time < ?")
+		args = append(args, filter.To.UTC().Format(auditTimeFormat))
+	}
+
+	query := "SELECT id, time, actor, request_id, action, task_id, before, after FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+	ctx, done := db.startQuery(ctx, "AuditLog", query)
+	defer done(&err)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения журнала аудита: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []moduls.AuditEntry{}
+	for rows.Next() {
+		var entry moduls.AuditEntry
+		var before, after sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.Time, &entry.Actor, &entry.RequestID,
+			&entry.Action, &entry.TaskID, &before, &after); err != nil {
+			return nil, fmt.Errorf("ошибка чтения записи аудита: %w", err)
+		}
+		if entry.Before, err = parseSnapshot(before); err != nil {
+			return nil, err
+		}
+		if entry.After, err = parseSnapshot(after); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// parseSnapshot разбирает сохранённое состояние задачи
+func parseSnapshot(data sql.NullString) (*moduls.Scheduler, error) {
+	if !data.Valid {
+		return nil, nil
+	}
+	var task moduls.Scheduler
+	if err := json.Unmarshal([]byte(data.String), &task); err != nil {
+		return nil, fmt.Errorf("ошибка разбора записи аудита: %w", err)
+	}
+	return &task, nil
+}
@@ -1,25 +1,37 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
+	"final-project/internal/audit"
 	"final-project/internal/cache"
+	"final-project/internal/clock"
+	"final-project/internal/logger"
+	"final-project/internal/metrics"
 	moduls "final-project/internal/moduls"
+	"final-project/internal/tracing"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
+// DefaultQueryTimeout время на один вызов метода DB по умолчанию
+const DefaultQueryTimeout = 5 * time.Second
+
 // DB представляет структуру базы данных с кэшем
 type DB struct {
 	*sql.DB
-	cache *cache.Cache
-	mu    sync.RWMutex
+	cache        *cache.Cache
+	clock        clock.Clock
+	queryTimeout time.Duration
+	mu           sync.RWMutex
 }
 
 var (
@@ -27,17 +39,33 @@ var (
 	once       sync.Once
 )
 
-// InitDatabase инициализирует подключение к базе данных
-func InitDatabase() *DB {
+// ErrTaskNotFound возвращается, если задача с указанным ID отсутствует
+var ErrTaskNotFound = errors.New("задача не найдена")
+
+// IsBusy сообщает, что запрос не выполнен, потому что база данных занята
+// или заблокирована другим соединением. Такой запрос можно повторить позже.
+func IsBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+}
+
+// InitDatabase инициализирует подключение к базе данных.
+// Фоновая очистка кэша останавливается при отмене ctx.
+func InitDatabase(ctx context.Context) *DB {
 	once.Do(func() {
 		dbFile := os.Getenv("TODO_DBFILE")
 		if dbFile == "" {
-			log.Fatal("Не задан файл базы данных")
+			slog.Error("Не задан файл базы данных")
+			os.Exit(1)
 		}
 
 		db, err := sql.Open("sqlite3", dbFile)
 		if err != nil {
-			log.Fatalf("Ошибка открытия базы данных: %v", err)
+			slog.Error("Ошибка открытия базы данных", "error", err)
+			os.Exit(1)
 		}
 
 		// Настройка пула соединений
@@ -47,13 +75,15 @@ func InitDatabase() *DB {
 
 		// Создание индексов
 		if err := createIndexes(db); err != nil {
-			log.Printf("Ошибка создания индексов: %v", err)
+			slog.Warn("Ошибка создания индексов", "error", err)
 		}
 
 		// Создание кэша
 		dbInstance = &DB{
-			DB:    db,
-			cache: cache.NewCache(),
+			DB:           db,
+			cache:        cache.NewCache(ctx, "tasks"),
+			clock:        clock.System{},
+			queryTimeout: DefaultQueryTimeout,
 		}
 	})
 
@@ -78,7 +108,7 @@ func createIndexes(db *sql.DB) error {
 }
 
 // ReadTask читает задачи с использованием кэша
-func (db *DB) ReadTask(date string) ([]moduls.Scheduler, error) {
+func (db *DB) ReadTask(ctx context.Context, date string) (tasks []moduls.Scheduler, err error) {
 	cacheKey := fmt.Sprintf("tasks_%s", date)
 
 	// Проверяем кэш
@@ -87,25 +117,22 @@ func (db *DB) ReadTask(date string) ([]moduls.Scheduler, error) {
 	}
 
 	// Если нет в кэше, читаем из БД
-	var tasks []moduls.Scheduler
-	var rows *sql.Rows
-	var err error
-
+	query := selectTasks + `
+			ORDER BY s.date
+		`
+	var args []interface{}
 	// Проверяем, есть ли дата в запросе
 	if date != "" {
-		rows, err = db.Query(`
-			SELECT id, date, title, comment, repeat 
-			FROM scheduler 
-			WHERE date = ? 
-			ORDER BY date
-		`, date)
-	} else {
-		rows, err = db.Query(`
-			SELECT id, date, title, comment, repeat 
-			FROM scheduler 
-			ORDER BY date
-		`)
+		query = selectTasks + `
+			WHERE s.date = ? 
+			ORDER BY s.date
+		`
+		args = append(args, date)
 	}
+	ctx, done := db.startQuery(ctx, "ReadTask", query)
+	defer done(&err)
+
+	rows, err := db.QueryContext(ctx, query, args...)
 
 	// Если есть ошибка, возвращаем ее
 	if err != nil {
@@ -115,13 +142,17 @@ func (db *DB) ReadTask(date string) ([]moduls.Scheduler, error) {
 
 	// Сканируем строки
 	for rows.Next() {
-		var task moduls.Scheduler
 		// Сканируем строки
-		if err := rows.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
 		}
 		tasks = append(tasks, task)
 	}
+	// Прерванный запрос не должен попасть в кэш неполным
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
 
 	// Сохраняем в кэш на 5 минут
 	db.cache.Set(cacheKey, tasks, 5*time.Minute)
@@ -130,11 +161,21 @@ func (db *DB) ReadTask(date string) ([]moduls.Scheduler, error) {
 }
 
 // Create добавляет новую задачу с инвалидацией кэша
-func (db *DB) Create(task *moduls.Scheduler) (int, error) {
-	result, err := db.Exec(`
+func (db *DB) Create(ctx context.Context, task *moduls.Scheduler) (_ int, err error) {
+	const query = `
 		INSERT INTO scheduler (date, title, comment, repeat) 
 		VALUES (?, ?, ?, ?)
-	`, task.Date, task.Title, task.Comment, task.Repeat)
+	`
+	ctx, done := db.startQuery(ctx, "Create", query)
+	defer done(&err)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, task.Date, task.Title, task.Comment, task.Repeat)
 	if err != nil {
 		return 0, err
 	}
@@ -145,18 +186,47 @@ func (db *DB) Create(task *moduls.Scheduler) (int, error) {
 		return 0, err
 	}
 
+	// Сохраняем условия окончания повтора
+	if err := saveRecurrence(ctx, tx, id, task); err != nil {
+		return 0, err
+	}
+	created := *task
+	created.ID = strconv.FormatInt(id, 10)
+	if err := writeAudit(ctx, tx, db.now(ctx), audit.ActionCreate, id, nil, &created); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	// Инвалидируем кэш
 	db.invalidateCache()
 	return int(id), nil
 }
 
 // Update обновляет задачу с инвалидацией кэша
-func (db *DB) Update(task *moduls.Scheduler) error {
-	result, err := db.Exec(`
+func (db *DB) Update(ctx context.Context, task *moduls.Scheduler) (err error) {
+	const query = `
 		UPDATE scheduler 
 		SET date = ?, title = ?, comment = ?, repeat = ? 
 		WHERE id = ?
-	`, task.Date, task.Title, task.Comment, task.Repeat, task.ID)
+	`
+	ctx, done := db.startQuery(ctx, "Update", query)
+	defer done(&err)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Состояние до изменения для журнала аудита; заодно проверяет, что задача есть
+	before, err := readTaskTx(ctx, tx, task.ID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, task.Date, task.Title, task.Comment, task.Repeat, task.ID)
 	if err != nil {
 		return err
 	}
@@ -169,7 +239,19 @@ func (db *DB) Update(task *moduls.Scheduler) error {
 
 	// Если строк нет, возвращаем ошибку
 	if rowsAffected == 0 {
-		return errors.New("задача не найдена")
+		return ErrTaskNotFound
+	}
+
+	// Сохраняем условия окончания повтора
+	if err := saveRecurrence(ctx, tx, task.ID, task); err != nil {
+		return err
+	}
+	after := *task
+	if err := writeAudit(ctx, tx, db.now(ctx), audit.ActionUpdate, task.ID, before, &after); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
 	// Инвалидируем кэш
@@ -178,8 +260,24 @@ func (db *DB) Update(task *moduls.Scheduler) error {
 }
 
 // Delete удаляет задачу с инвалидацией кэша
-func (db *DB) Delete(id string) error {
-	result, err := db.Exec("DELETE FROM scheduler WHERE id = ?", id)
+func (db *DB) Delete(ctx context.Context, id string) (err error) {
+	const query = "DELETE FROM scheduler WHERE id = ?"
+	ctx, done := db.startQuery(ctx, "Delete", query)
+	defer done(&err)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Состояние до удаления для журнала аудита
+	before, err := readTaskTx(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -191,7 +289,24 @@ func (db *DB) Delete(id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("задача не найдена")
+		return ErrTaskNotFound
+	}
+
+	// Удаляем связи удалённой задачи с другими задачами
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_dependencies WHERE task_id = ? OR depends_on_id = ?", id, id); err != nil {
+		return fmt.Errorf("ошибка удаления зависимостей: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_recurrence WHERE task_id = ?", id); err != nil {
+		return fmt.Errorf("ошибка удаления условий повтора: %w", err)
+	}
+	if err := deleteTaskExceptions(ctx, tx, id); err != nil {
+		return err
+	}
+	if err := writeAudit(ctx, tx, db.now(ctx), audit.ActionDelete, id, before, nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
 	// Инвалидируем кэш
@@ -199,11 +314,66 @@ func (db *DB) Delete(id string) error {
 	return nil
 }
 
-// invalidateCache очищает кэш
-func (db *DB) invalidateCache() {
+// SetClock заменяет источник текущего времени (например, на clock.Fake в тестах
+// или при TODO_DEBUG_NOW). По умолчанию используются системные часы.
+func (db *DB) SetClock(c clock.Clock) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
-	db.cache = cache.NewCache()
+	db.clock = c
+}
+
+// Clock возвращает источник текущего времени
+func (db *DB) Clock() clock.Clock {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.clock
+}
+
+// Now возвращает текущее время по часам базы данных, поэтому DB
+// сам может использоваться как clock.Clock
+func (db *DB) Now() time.Time {
+	return db.Clock().Now()
+}
+
+// now текущее время для вызова с контекстом ctx: часы запроса
+// (с подменой X-Debug-Now), а без них — часы базы данных
+func (db *DB) now(ctx context.Context) time.Time {
+	return clock.FromContextOr(ctx, db.Clock()).Now()
+}
+
+// SetQueryTimeout задаёт время на один вызов метода DB: запрос или транзакцию.
+// Ноль отключает ограничение, остаётся только контекст вызывающего.
+func (db *DB) SetQueryTimeout(d time.Duration) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.queryTimeout = d
+}
+
+// startQuery начинает вызов метода method: ограничивает контекст тайм-аутом
+// запроса, открывает span с текстом запроса query (пустой — у методов из
+// нескольких запросов) и засекает время для метрик. Возвращённую функцию
+// нужно отложить с указателем на ошибку метода: defer done(&err).
+func (db *DB) startQuery(ctx context.Context, method, query string) (context.Context, func(*error)) {
+	start := time.Now()
+	db.mu.RLock()
+	timeout := db.queryTimeout
+	db.mu.RUnlock()
+
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	ctx, span := tracing.StartQuery(ctx, method, query)
+	return ctx, func(err *error) {
+		tracing.End(span, *err)
+		cancel()
+		metrics.ObserveQuery(method, start)
+	}
+}
+
+// invalidateCache очищает кэш
+func (db *DB) invalidateCache() {
+	db.cache.Clear()
 }
 
 // Функция для проверки соединения с базой данных
@@ -220,47 +390,52 @@ func TestDatabaseConnection(db *sql.DB) error {
         CREATE INDEX IF NOT EXISTS idx_date ON scheduler(date);
     `)
 	if err != nil {
-		log.Printf("Ошибка выполнения тестового запроса: %v", err)
 		return fmt.Errorf("ошибка выполнения тестового запроса: %w", err)
 	}
-	log.Println("Тестовый запрос к базе данных выполнен успешно")
+
+	// Применяем миграции схемы
+	if err := migrate(db); err != nil {
+		return err
+	}
+	slog.Info("Тестовый запрос к базе данных выполнен успешно")
 	return nil
 }
 
 // GetpoID получает задачу по ID
-func (db *DB) GetpoID(id string) (moduls.Scheduler, error) {
+func (db *DB) GetpoID(ctx context.Context, id string) (_ moduls.Scheduler, err error) {
 	if db == nil {
 		return moduls.Scheduler{}, errors.New("database not initialized")
 	}
-	var task moduls.Scheduler
-	log.Printf("Получение задачи с ID: %s", id)
+	const query = selectTasks + " WHERE s.id = ?"
+	ctx, done := db.startQuery(ctx, "GetpoID", query)
+	defer done(&err)
+	logger.FromContext(ctx).Debug("Получение задачи", "id", id)
 
 	// Используем ? placeholders для безопасного выполнения запроса
-	row := db.QueryRow("SELECT id, date, title, comment, repeat FROM scheduler WHERE id = ?", id)
-	err := row.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat)
+	task, err := scanTask(db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			log.Printf("Задача с ID %s не найдена", id)
-			return moduls.Scheduler{}, fmt.Errorf("задача с ID %s не найдена", id)
+			logger.FromContext(ctx).Debug("Задача не найдена", "id", id)
+			return moduls.Scheduler{}, fmt.Errorf("%w: ID %s", ErrTaskNotFound, id)
 		}
-		log.Printf("Ошибка при получении задачи: %v", err)
-		return moduls.Scheduler{}, fmt.Errorf("ошибка при получении задачи: %v", err)
+		return moduls.Scheduler{}, fmt.Errorf("ошибка при получении задачи: %w", err)
 	}
-	log.Printf("Задача найдена: %+v", task)
+	logger.FromContext(ctx).Debug("Задача найдена", "task", task)
 	return task, nil
 }
 
 // SearchDate ищет задачи по дате
-func (db *DB) SearchDate(date string) ([]moduls.Scheduler, error) {
-	log.Printf("SearchDate вызван с параметром: %s", date)
-	query := `
-        SELECT id, date, title, comment, repeat 
-        FROM scheduler 
-        WHERE date = ? 
-        ORDER BY date ASC
+func (db *DB) SearchDate(ctx context.Context, date string) (_ []moduls.Scheduler, err error) {
+
+	slog.Debug("Поиск задач по дате", "date", date)
+	query := selectTasks + `
+        WHERE s.date = ? 
+        ORDER BY s.date ASC
     `
+	ctx, done := db.startQuery(ctx, "SearchDate", query)
+	defer done(&err)
 
-	rows, err := db.Query(query, date)
+	rows, err := db.QueryContext(ctx, query, date)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса: %w", err)
 	}
@@ -268,13 +443,17 @@ func (db *DB) SearchDate(date string) ([]moduls.Scheduler, error) {
 
 	var tasks []moduls.Scheduler
 	for rows.Next() {
-		var task moduls.Scheduler
-		if err := rows.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			return nil, fmt.Errorf("ошибка сканирования: %w", err)
 		}
 		tasks = append(tasks, task)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
 	// Всегда возвращаем пустой слайс, если нет результатов
 	if tasks == nil {
 		return []moduls.Scheduler{}, nil
@@ -284,16 +463,18 @@ func (db *DB) SearchDate(date string) ([]moduls.Scheduler, error) {
 }
 
 // Searchtitl ищет задачи по названию
-func (db *DB) Searchtitl(search string) ([]moduls.Scheduler, error) {
-	log.Printf("Searchtitl вызван с параметром: %s", search)
-
-	query := `
-        SELECT id, date, title, comment, repeat 
-        FROM scheduler 
-        WHERE title LIKE ? 
-        ORDER BY date ASC
+func (db *DB) Searchtitl(ctx context.Context, search string) (_ []moduls.Scheduler, err error) {
+
+	slog.Debug("Поиск задач по названию", "search", search)
+
+	query := selectTasks + `
+        WHERE s.title LIKE ? 
+        ORDER BY s.date ASC
     `
-	rows, err := db.Query(query, "%"+search+"%") // Используем LIKE для поиска по названию
+	ctx, done := db.startQuery(ctx, "Searchtitl", query)
+	defer done(&err)
+
+	rows, err := db.QueryContext(ctx, query, "%"+search+"%") // Используем LIKE для поиска по названию
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса: %w", err)
 	}
@@ -301,11 +482,43 @@ func (db *DB) Searchtitl(search string) ([]moduls.Scheduler, error) {
 
 	var tasks []moduls.Scheduler
 	for rows.Next() {
-		var task moduls.Scheduler
-		if err := rows.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			return nil, fmt.Errorf("ошибка сканирования: %w", err)
 		}
 		tasks = append(tasks, task)
 	}
-	return tasks, nil
+	return tasks, rows.Err()
+}
+
+// CountByRepeat возвращает число задач по типу правила повтора: первому слову
+// правила (d, w, m, mw, y, b), а для задач без повтора — "none"
+func (db *DB) CountByRepeat(ctx context.Context) (_ map[string]int, err error) {
+	const query = `
+		SELECT CASE
+			WHEN TRIM(COALESCE(repeat, '')) = '' THEN 'none'
+			ELSE substr(TRIM(repeat), 1, instr(TRIM(repeat) || ' ', ' ') - 1)
+		END AS kind, COUNT(*)
+		FROM scheduler
+		GROUP BY kind
+	`
+	ctx, done := db.startQuery(ctx, "CountByRepeat", query)
+	defer done(&err)
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчёта задач: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var kind string
+		var n int
+		if err := rows.Scan(&kind, &n); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		counts[kind] = n
+	}
+	return counts, rows.Err()
 }
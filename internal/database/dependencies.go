@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	moduls "final-project/internal/moduls"
+)
+
+var (
+	// ErrDependencyCycle возвращается, если новая зависимость образует цикл
+	ErrDependencyCycle = errors.New("зависимость образует цикл")
+	// ErrSelfDependency возвращается при попытке сделать задачу зависимой от самой себя
+	ErrSelfDependency = errors.New("задача не может зависеть от самой себя")
+	// ErrDependencyNotFound возвращается, если удаляемой зависимости нет
+	ErrDependencyNotFound = errors.New("зависимость не найдена")
+)
+
+// AddDependency добавляет зависимость: задача taskID не может быть выполнена раньше blockerID
+func (db *DB) AddDependency(ctx context.Context, taskID, blockerID string) (err error) {
+	ctx, done := db.startQuery(ctx, "AddDependency", "")
+	defer done(&err)
+
+	if taskID == blockerID {
+		return ErrSelfDependency
+	}
+
+	// Проверяем, что обе задачи существуют
+	for _, id := range []string{taskID, blockerID} {
+		if err := db.taskExists(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	// Если taskID уже достижима из blockerID по цепочке зависимостей,
+	// новая связь замкнёт цикл
+	var found int
+	err = db.QueryRowContext(ctx, `
+		WITH RECURSIVE chain(id) AS (
+			SELECT depends_on_id FROM task_dependencies WHERE task_id = ?
+			UNION
+			SELECT d.depends_on_id FROM task_dependencies d JOIN chain c ON d.task_id = c.id
+		)
+		SELECT 1 FROM chain WHERE id = ? LIMIT 1
+	`, blockerID, taskID).Scan(&found)
+	if err == nil {
+		return ErrDependencyCycle
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("ошибка проверки цикла зависимостей: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO task_dependencies (task_id, depends_on_id)
+		VALUES (?, ?)
+	`, taskID, blockerID)
+	if err != nil {
+		return fmt.Errorf("ошибка добавления зависимости: %w", err)
+	}
+	return nil
+}
+
+// RemoveDependency удаляет зависимость задачи taskID от blockerID
+func (db *DB) RemoveDependency(ctx context.Context, taskID, blockerID string) (err error) {
+	const query = `
+		DELETE FROM task_dependencies
+		WHERE task_id = ? AND depends_on_id = ?
+	`
+	ctx, done := db.startQuery(ctx, "RemoveDependency", query)
+	defer done(&err)
+
+	result, err := db.ExecContext(ctx, query, taskID, blockerID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления зависимости: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrDependencyNotFound
+	}
+	return nil
+}
+
+// Blockers возвращает задачи, от которых зависит задача с указанным ID
+func (db *DB) Blockers(ctx context.Context, id string) (_ []moduls.Scheduler, err error) {
+	const query = selectTasks + `
+		JOIN task_dependencies d ON s.id = d.depends_on_id
+		WHERE d.task_id = ?
+		ORDER BY s.date ASC
+	`
+	ctx, done := db.startQuery(ctx, "Blockers", query)
+	defer done(&err)
+
+	return db.queryDependencies(ctx, query, id)
+}
+
+// Blocking возвращает задачи, которые зависят от задачи с указанным ID
+func (db *DB) Blocking(ctx context.Context, id string) (_ []moduls.Scheduler, err error) {
+	const query = selectTasks + `
+		JOIN task_dependencies d ON s.id = d.task_id
+		WHERE d.depends_on_id = ?
+		ORDER BY s.date ASC
+	`
+	ctx, done := db.startQuery(ctx, "Blocking", query)
+	defer done(&err)
+
+	return db.queryDependencies(ctx, query, id)
+}
+
+// OpenBlockers возвращает незавершённые блокирующие задачи.
+// Разовая задача при выполнении удаляется, поэтому блокирует, пока существует,
+// независимо от даты. У повторяющейся задачи дата переносится вперёд, и она
+// блокирует, только если её дата не позже даты зависимой задачи.
+func (db *DB) OpenBlockers(ctx context.Context, task moduls.Scheduler) (_ []moduls.Scheduler, err error) {
+	const query = selectTasks + `
+		JOIN task_dependencies d ON s.id = d.depends_on_id
+		WHERE d.task_id = ? AND (TRIM(COALESCE(s.repeat, '')) = '' OR s.date <= ?)
+		ORDER BY s.date ASC
+	`
+	ctx, done := db.startQuery(ctx, "OpenBlockers", query)
+	defer done(&err)
+
+	return db.queryDependencies(ctx, query, task.ID, task.Date)
+}
+
+// queryDependencies выполняет запрос и сканирует список задач
+func (db *DB) queryDependencies(ctx context.Context, query string, args ...interface{}) ([]moduls.Scheduler, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса зависимостей: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []moduls.Scheduler{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
@@ -0,0 +1,266 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	moduls "final-project/internal/moduls"
+)
+
+var (
+	// ErrExceptionNotFound возвращается, если удаляемой даты-исключения нет
+	ErrExceptionNotFound = errors.New("дата-исключение не найдена")
+	// ErrCalendarNotFound возвращается, если календарь с указанным ID отсутствует
+	ErrCalendarNotFound = errors.New("календарь не найден")
+)
+
+// CalendarDate дата календаря исключений с описанием
+type CalendarDate struct {
+	Date    string
+	Summary string
+}
+
+// AddException добавляет дату, на которую не выпадает повторение задачи
+func (db *DB) AddException(ctx context.Context, taskID, date string) (err error) {
+	ctx, done := db.startQuery(ctx, "AddException", "")
+	defer done(&err)
+
+	if err := db.taskExists(ctx, taskID); err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO task_exceptions (task_id, date)
+		VALUES (?, ?)
+	`, taskID, date)
+	if err != nil {
+		return fmt.Errorf("ошибка добавления исключения: %w", err)
+	}
+	return nil
+}
+
+// RemoveException удаляет дату-исключение задачи
+func (db *DB) RemoveException(ctx context.Context, taskID, date string) (err error) {
+	const query = "DELETE FROM task_exceptions WHERE task_id = ? AND date = ?"
+	ctx, done := db.startQuery(ctx, "RemoveException", query)
+	defer done(&err)
+
+	result, err := db.ExecContext(ctx, query, taskID, date)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления исключения: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrExceptionNotFound
+	}
+	return nil
+}
+
+// TaskExceptions возвращает собственные даты-исключения задачи и подключённые календари
+func (db *DB) TaskExceptions(ctx context.Context, taskID string) (_ moduls.TaskExceptions, err error) {
+	ctx, done := db.startQuery(ctx, "TaskExceptions", "")
+	defer done(&err)
+
+	result := moduls.TaskExceptions{Dates: []string{}, Calendars: []moduls.Calendar{}}
+
+	rows, err := db.QueryContext(ctx, "SELECT date FROM task_exceptions WHERE task_id = ? ORDER BY date", taskID)
+	if err != nil {
+		return result, fmt.Errorf("ошибка запроса исключений: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return result, fmt.Errorf("ошибка сканирования: %w", err)
+		}
+		result.Dates = append(result.Dates, date)
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	result.Calendars, err = db.queryCalendars(ctx, `
+		SELECT c.id, c.name, (SELECT count(*) FROM calendar_dates cd WHERE cd.calendar_id = c.id)
+		FROM calendars c
+		JOIN task_calendars tc ON tc.calendar_id = c.id
+		WHERE tc.task_id = ?
+		ORDER BY c.name
+	`, taskID)
+	return result, err
+}
+
+// ExceptionDates возвращает все даты, которые пропускаются при повторе задачи:
+// собственные исключения и даты подключённых календарей
+func (db *DB) ExceptionDates(ctx context.Context, taskID string) (_ map[string]bool, err error) {
+	const query = `
+		SELECT date FROM task_exceptions WHERE task_id = ?
+		UNION
+		SELECT cd.date FROM calendar_dates cd
+		JOIN task_calendars tc ON tc.calendar_id = cd.calendar_id
+		WHERE tc.task_id = ?
+	`
+	ctx, done := db.startQuery(ctx, "ExceptionDates", query)
+	defer done(&err)
+
+	rows, err := db.QueryContext(ctx, query, taskID, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса исключений: %w", err)
+	}
+	defer rows.Close()
+
+	dates := make(map[string]bool)
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования: %w", err)
+		}
+		dates[date] = true
+	}
+	return dates, rows.Err()
+}
+
+// ImportCalendar создаёт календарь с указанным именем или заменяет даты существующего
+func (db *DB) ImportCalendar(ctx context.Context, name string, dates []CalendarDate) (_ int, err error) {
+	ctx, done := db.startQuery(ctx, "ImportCalendar", "")
+	defer done(&err)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "INSERT OR IGNORE INTO calendars (name) VALUES (?)", name)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка создания календаря: %w", err)
+	}
+	var id int
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM calendars WHERE name = ?", name).Scan(&id); err != nil {
+		return 0, fmt.Errorf("ошибка получения календаря: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM calendar_dates WHERE calendar_id = ?", id); err != nil {
+		return 0, fmt.Errorf("ошибка очистки календаря: %w", err)
+	}
+	for _, d := range dates {
+		_, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO calendar_dates (calendar_id, date, summary)
+			VALUES (?, ?, ?)
+		`, id, d.Date, d.Summary)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка добавления даты календаря: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Calendars возвращает список календарей исключений
+func (db *DB) Calendars(ctx context.Context) (_ []moduls.Calendar, err error) {
+	const query = `
+		SELECT c.id, c.name, (SELECT count(*) FROM calendar_dates cd WHERE cd.calendar_id = c.id)
+		FROM calendars c
+		ORDER BY c.name
+	`
+	ctx, done := db.startQuery(ctx, "Calendars", query)
+	defer done(&err)
+
+	return db.queryCalendars(ctx, query)
+}
+
+// LinkCalendar подключает календарь исключений к задаче
+func (db *DB) LinkCalendar(ctx context.Context, taskID, calendarID string) (err error) {
+	ctx, done := db.startQuery(ctx, "LinkCalendar", "")
+	defer done(&err)
+
+	if err := db.taskExists(ctx, taskID); err != nil {
+		return err
+	}
+	var exists int
+	err = db.QueryRowContext(ctx, "SELECT 1 FROM calendars WHERE id = ?", calendarID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrCalendarNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка проверки календаря: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO task_calendars (task_id, calendar_id)
+		VALUES (?, ?)
+	`, taskID, calendarID)
+	if err != nil {
+		return fmt.Errorf("ошибка подключения календаря: %w", err)
+	}
+	return nil
+}
+
+// UnlinkCalendar отключает календарь исключений от задачи
+func (db *DB) UnlinkCalendar(ctx context.Context, taskID, calendarID string) (err error) {
+	const query = "DELETE FROM task_calendars WHERE task_id = ? AND calendar_id = ?"
+	ctx, done := db.startQuery(ctx, "UnlinkCalendar", query)
+	defer done(&err)
+
+	result, err := db.ExecContext(ctx, query, taskID, calendarID)
+	if err != nil {
+		return fmt.Errorf("ошибка отключения календаря: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrCalendarNotFound
+	}
+	return nil
+}
+
+// deleteTaskExceptions удаляет исключения и подключения календарей удалённой задачи
+func deleteTaskExceptions(ctx context.Context, tx *sql.Tx, taskID string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_exceptions WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("ошибка удаления исключений: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_calendars WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("ошибка отключения календарей: %w", err)
+	}
+	return nil
+}
+
+// taskExists проверяет, что задача с указанным ID существует
+func (db *DB) taskExists(ctx context.Context, id string) error {
+	var exists int
+	err := db.QueryRowContext(ctx, "SELECT 1 FROM scheduler WHERE id = ?", id).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrTaskNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка проверки задачи: %w", err)
+	}
+	return nil
+}
+
+// queryCalendars выполняет запрос и сканирует список календарей
+func (db *DB) queryCalendars(ctx context.Context, query string, args ...interface{}) ([]moduls.Calendar, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса календарей: %w", err)
+	}
+	defer rows.Close()
+
+	calendars := []moduls.Calendar{}
+	for rows.Next() {
+		var c moduls.Calendar
+		if err := rows.Scan(&c.ID, &c.Name, &c.Dates); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования: %w", err)
+		}
+		calendars = append(calendars, c)
+	}
+	return calendars, rows.Err()
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// migrations содержит упорядоченный список изменений схемы.
+// Номер миграции равен её позиции в списке (начиная с 1) и
+// сохраняется в PRAGMA user_version.
+var migrations = []string{
+	// 1: зависимости между задачами
+	`CREATE TABLE IF NOT EXISTS task_dependencies (
+		task_id INTEGER NOT NULL,
+		depends_on_id INTEGER NOT NULL,
+		PRIMARY KEY (task_id, depends_on_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_dependencies_depends_on ON task_dependencies(depends_on_id);`,
+	// 2: условия окончания повтора
+	`CREATE TABLE IF NOT EXISTS task_recurrence (
+		task_id INTEGER PRIMARY KEY,
+		until TEXT NOT NULL DEFAULT '',
+		count INTEGER NOT NULL DEFAULT 0
+	);`,
+	// 3: исходная дата отложенного повторения
+	`ALTER TABLE task_recurrence ADD COLUMN anchor TEXT NOT NULL DEFAULT '';`,
+	// 4: даты-исключения и общие календари исключений
+	`CREATE TABLE IF NOT EXISTS task_exceptions (
+		task_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		PRIMARY KEY (task_id, date)
+	);
+	CREATE TABLE IF NOT EXISTS calendars (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE
+	);
+	CREATE TABLE IF NOT EXISTS calendar_dates (
+		calendar_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		summary TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (calendar_id, date)
+	);
+	CREATE TABLE IF NOT EXISTS task_calendars (
+		task_id INTEGER NOT NULL,
+		calendar_id INTEGER NOT NULL,
+		PRIMARY KEY (task_id, calendar_id)
+	);`,
+	// 5: журнал аудита изменений задач
+	`CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		time TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		request_id TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL,
+		task_id INTEGER NOT NULL,
+		before TEXT,
+		after TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_task ON audit_log(task_id);
+	CREATE INDEX IF NOT EXISTS idx_audit_actor ON audit_log(actor);
+	CREATE INDEX IF NOT EXISTS idx_audit_time ON audit_log(time);`,
+	// 6: настройки пользователей
+	`CREATE TABLE IF NOT EXISTS user_settings (
+		actor TEXT PRIMARY KEY,
+		timezone TEXT NOT NULL DEFAULT ''
+	);`,
+}
+
+// LatestSchemaVersion номер последней миграции, известной этой версии сервера
+func LatestSchemaVersion() int {
+	return len(migrations)
+}
+
+// SchemaVersion возвращает номер последней применённой к базе миграции
+func (db *DB) SchemaVersion(ctx context.Context) (_ int, err error) {
+	const query = "PRAGMA user_version"
+	ctx, done := db.startQuery(ctx, "SchemaVersion", query)
+	defer done(&err)
+
+	var version int
+	if err := db.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return 0, fmt.Errorf("ошибка чтения версии схемы: %w", err)
+	}
+	return version, nil
+}
+
+// JournalMode возвращает режим журнала SQLite (delete, wal, ...)
+func (db *DB) JournalMode(ctx context.Context) (_ string, err error) {
+	const query = "PRAGMA journal_mode"
+	ctx, done := db.startQuery(ctx, "JournalMode", query)
+	defer done(&err)
+
+	var mode string
+	if err := db.QueryRowContext(ctx, query).Scan(&mode); err != nil {
+		return "", fmt.Errorf("ошибка чтения режима журнала: %w", err)
+	}
+	return mode, nil
+}
+
+// migrate применяет миграции, которые ещё не были применены к базе данных
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("ошибка чтения версии схемы: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("ошибка начала транзакции: %w", err)
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("ошибка применения миграции %d: %w", i+1, err)
+		}
+		// PRAGMA не поддерживает параметры, поэтому версия подставляется напрямую
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("ошибка обновления версии схемы: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("ошибка фиксации миграции %d: %w", i+1, err)
+		}
+		slog.Info("Применена миграция", "version", i+1)
+	}
+	return nil
+}
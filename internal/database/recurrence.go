@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	moduls "final-project/internal/moduls"
+)
+
+// selectTasks выбирает задачи вместе с условиями окончания повтора.
+// Условия хранятся в отдельной таблице task_recurrence, чтобы не менять
+// набор столбцов таблицы scheduler.
+const selectTasks = `
+	SELECT s.id, s.date, s.title, s.comment, s.repeat,
+		COALESCE(r.until, ''), COALESCE(r.count, 0), COALESCE(r.anchor, '')
+	FROM scheduler s
+	LEFT JOIN task_recurrence r ON r.task_id = s.id`
+
+// rowScanner общий интерфейс для *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask сканирует строку, выбранную запросом selectTasks
+func scanTask(row rowScanner) (moduls.Scheduler, error) {
+	var task moduls.Scheduler
+	err := row.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat,
+		&task.Until, &task.Count, &task.Anchor)
+	return task, err
+}
+
+// saveRecurrence сохраняет условия окончания повтора и исходную дату
+// отложенного повторения. Если ничего из этого не задано, запись удаляется.
+func saveRecurrence(ctx context.Context, tx *sql.Tx, id interface{}, task *moduls.Scheduler) error {
+	if task.Until == "" && task.Count == 0 && task.Anchor == "" {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM task_recurrence WHERE task_id = ?", id); err != nil {
+			return fmt.Errorf("ошибка удаления условий повтора: %w", err)
+		}
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO task_recurrence (task_id, until, count, anchor)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(task_id) DO UPDATE SET
+			until = excluded.until, count = excluded.count, anchor = excluded.anchor
+	`, id, task.Until, task.Count, task.Anchor)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения условий повтора: %w", err)
+	}
+	return nil
+}
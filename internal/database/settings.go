@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// UserTimezone возвращает сохранённый часовой пояс пользователя actor
+// или пустую строку, если он не задан
+func (db *DB) UserTimezone(ctx context.Context, actor string) (_ string, err error) {
+	const query = "SELECT timezone FROM user_settings WHERE actor = ?"
+	ctx, done := db.startQuery(ctx, "UserTimezone", query)
+	defer done(&err)
+
+	var name string
+	err = db.QueryRowContext(ctx, query, actor).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения часового пояса: %w", err)
+	}
+	return name, nil
+}
+
+// SetUserTimezone сохраняет часовой пояс пользователя actor.
+// Пустое имя удаляет настройку.
+func (db *DB) SetUserTimezone(ctx context.Context, actor, name string) (err error) {
+	query := `
+		INSERT INTO user_settings (actor, timezone) VALUES (?, ?)
+		ON CONFLICT(actor) DO UPDATE SET timezone = excluded.timezone
+	`
+	args := []interface{}{actor, name}
+	if name == "" {
+		query = "DELETE FROM user_settings WHERE actor = ?"
+		args = args[:1]
+	}
+	ctx, done := db.startQuery(ctx, "SetUserTimezone", query)
+	defer done(&err)
+
+	if _, err := db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("ошибка сохранения часового пояса: %w", err)
+	}
+	return nil
+}
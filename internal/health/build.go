@@ -0,0 +1,41 @@
+package health
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Version версия сервера, задаётся при сборке:
+// go build -ldflags "-X final-project/internal/health.Version=v1.2.3"
+var Version = "dev"
+
+// Build сведения о сборке сервера
+type Build struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	// Revision, Time и Modified берутся из данных системы контроля версий,
+	// которые go build добавляет при сборке пакета из репозитория
+	Revision string `json:"revision,omitempty"`
+	Time     string `json:"time,omitempty"`
+	Modified bool   `json:"modified,omitempty"`
+}
+
+// buildInfo возвращает сведения о сборке
+func buildInfo() Build {
+	build := Build{Version: Version, GoVersion: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.time":
+			build.Time = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+	return build
+}
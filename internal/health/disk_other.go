@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package health
+
+import "errors"
+
+// diskFree не поддерживается на этой платформе, свободное место не показывается
+func diskFree(dir string) (uint64, error) {
+	return 0, errors.New("определение свободного места не поддерживается")
+}
@@ -0,0 +1,178 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"final-project/internal/apierror"
+	"final-project/internal/cache"
+	"final-project/internal/database"
+	"final-project/internal/utils"
+)
+
+// checkTimeout сколько ждать ответа базы данных при проверке
+const checkTimeout = 2 * time.Second
+
+// Состояния в ответе /api/health/details
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// Checker проверяет состояние сервера для оркестратора и дежурных
+type Checker struct {
+	db      *database.DB
+	dbFile  string
+	started time.Time
+}
+
+// Details подробное состояние сервера
+type Details struct {
+	Status        string        `json:"status"`
+	Started       time.Time     `json:"started"`
+	UptimeSeconds int64         `json:"uptime_seconds"`
+	Database      Database      `json:"database"`
+	Caches        []cache.Stats `json:"caches"`
+	Workers       []Worker      `json:"workers"`
+	Build         Build         `json:"build"`
+}
+
+// Database состояние базы данных. Размеры и свободное место отсутствуют,
+// если их не удалось определить.
+type Database struct {
+	Status        string  `json:"status"`
+	Error         string  `json:"error,omitempty"`
+	LatencyMS     float64 `json:"latency_ms"`
+	SchemaVersion int     `json:"schema_version"`
+	SchemaLatest  int     `json:"schema_latest"`
+	JournalMode   string  `json:"journal_mode,omitempty"`
+	File          string  `json:"file"`
+	SizeBytes     *int64  `json:"size_bytes,omitempty"`
+	WALBytes      *int64  `json:"wal_bytes,omitempty"`
+	DiskFreeBytes *uint64 `json:"disk_free_bytes,omitempty"`
+}
+
+// Worker состояние фоновой задачи
+type Worker struct {
+	Name    string `json:"name"`
+	Running bool   `json:"running"`
+}
+
+// NewChecker создаёт проверку для базы данных db, хранящейся в файле dbFile
+func NewChecker(db *database.DB, dbFile string) *Checker {
+	return &Checker{db: db, dbFile: dbFile, started: time.Now()}
+}
+
+// Live обрабатывает /api/health/live: процесс работает и отвечает на запросы.
+// База данных не проверяется, чтобы её недоступность не приводила к перезапуску.
+func (c *Checker) Live(w http.ResponseWriter, r *http.Request) {
+	utils.SendJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// Ready обрабатывает /api/health/ready: сервер готов принимать запросы,
+// если база данных отвечает и её схема не старше ожидаемой
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	if err := c.db.PingContext(ctx); err != nil {
+		apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, apierror.CodeServiceUnavailable).Wrap(err))
+		return
+	}
+	version, err := c.db.SchemaVersion(ctx)
+	if err != nil {
+		apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, apierror.CodeServiceUnavailable).Wrap(err))
+		return
+	}
+	if latest := database.LatestSchemaVersion(); version < latest {
+		err := fmt.Errorf("версия схемы %d, ожидается %d", version, latest)
+		apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, apierror.CodeServiceUnavailable).Wrap(err))
+		return
+	}
+	utils.SendJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// Details обрабатывает /api/health/details. Отвечает 503, если база данных
+// недоступна; при остальных неполадках состояние — degraded.
+func (c *Checker) Details(w http.ResponseWriter, r *http.Request) {
+	details := Details{
+		Status:        StatusOK,
+		Started:       c.started.UTC(),
+		UptimeSeconds: int64(time.Since(c.started).Seconds()),
+		Database:      c.database(r.Context()),
+		Caches:        cache.All(),
+		Build:         buildInfo(),
+	}
+	for _, stats := range details.Caches {
+		worker := Worker{Name: "cache cleanup: " + stats.Name, Running: stats.Cleanup}
+		details.Workers = append(details.Workers, worker)
+		if !worker.Running {
+			details.Status = StatusDegraded
+		}
+	}
+	switch details.Database.Status {
+	case StatusFail:
+		details.Status = StatusFail
+	case StatusDegraded:
+		details.Status = StatusDegraded
+	}
+
+	status := http.StatusOK
+	if details.Status == StatusFail {
+		status = http.StatusServiceUnavailable
+	}
+	utils.SendJSON(w, status, details)
+}
+
+// database проверяет базу данных и собирает сведения о её файлах
+func (c *Checker) database(ctx context.Context) Database {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	result := Database{
+		Status:       StatusOK,
+		File:         c.dbFile,
+		SchemaLatest: database.LatestSchemaVersion(),
+	}
+
+	start := time.Now()
+	if err := c.db.PingContext(ctx); err != nil {
+		result.Status, result.Error = StatusFail, err.Error()
+		return result
+	}
+	result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+
+	version, err := c.db.SchemaVersion(ctx)
+	if err != nil {
+		result.Status, result.Error = StatusFail, err.Error()
+		return result
+	}
+	result.SchemaVersion = version
+	if version != result.SchemaLatest {
+		// База мигрирована другой версией сервера или миграции не применились
+		result.Status = StatusDegraded
+	}
+	if mode, err := c.db.JournalMode(ctx); err == nil {
+		result.JournalMode = mode
+	}
+
+	if info, err := os.Stat(c.dbFile); err == nil {
+		size := info.Size()
+		result.SizeBytes = &size
+	}
+	// Файла -wal нет, пока база не в режиме WAL или журнал пуст
+	var walSize int64
+	if info, err := os.Stat(c.dbFile + "-wal"); err == nil {
+		walSize = info.Size()
+	}
+	result.WALBytes = &walSize
+	if free, err := diskFree(filepath.Dir(c.dbFile)); err == nil {
+		result.DiskFreeBytes = &free
+	}
+	return result
+}
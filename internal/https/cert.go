@@ -0,0 +1,121 @@
+package https
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"final-project/internal/moduls"
+)
+
+// Самоподписанный сертификат действует год и создаётся заново,
+// если до окончания осталось меньше renewBefore
+const (
+	selfSignedValidity = 365 * 24 * time.Hour
+	renewBefore        = 7 * 24 * time.Hour
+)
+
+// selfSigned возвращает самоподписанный сертификат. Если заданы файлы
+// сертификата и ключа, сертификат читается из них, а при их отсутствии
+// или скором окончании срока создаётся и сохраняется, чтобы исключение
+// в браузере сохранялось между перезапусками.
+func selfSigned(cfg moduls.TLS) (tls.Certificate, error) {
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err == nil {
+			cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		}
+		switch {
+		case err == nil && time.Until(cert.Leaf.NotAfter) > renewBefore:
+			return cert, nil
+		case err == nil:
+			slog.Info("Срок самоподписанного сертификата истекает, создаётся новый", "file", cfg.CertFile)
+		case !errors.Is(err, fs.ErrNotExist):
+			return tls.Certificate{}, err
+		}
+	}
+
+	hosts := cfg.Hosts
+	if len(hosts) == 0 {
+		hosts = defaultHosts()
+	}
+	certPEM, keyPEM, err := generate(hosts, time.Now())
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	slog.Warn("Используется самоподписанный сертификат, только для разработки", "hosts", hosts)
+
+	if cfg.CertFile != "" {
+		if err := os.WriteFile(cfg.CertFile, certPEM, 0o644); err != nil {
+			return tls.Certificate{}, fmt.Errorf("ошибка сохранения сертификата: %w", err)
+		}
+		if err := os.WriteFile(cfg.KeyFile, keyPEM, 0o600); err != nil {
+			return tls.Certificate{}, fmt.Errorf("ошибка сохранения ключа: %w", err)
+		}
+		slog.Info("Самоподписанный сертификат сохранён", "cert", cfg.CertFile, "key", cfg.KeyFile)
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// defaultHosts имена, по которым к серверу обращаются при разработке
+func defaultHosts() []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if name, err := os.Hostname(); err == nil && name != "" && name != "localhost" {
+		hosts = append(hosts, name)
+	}
+	return hosts
+}
+
+// generate создаёт сертификат на ключе ECDSA P-256 для имён и адресов hosts
+// и возвращает сертификат и ключ в формате PEM
+func generate(hosts []string, now time.Time) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка создания ключа: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка создания серийного номера: %w", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Scheduler (self-signed)"}, CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка создания сертификата: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка сохранения ключа: %w", err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
@@ -0,0 +1,98 @@
+package https
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"final-project/internal/moduls"
+)
+
+// DefaultHSTSMaxAge срок HSTS по умолчанию — год
+const DefaultHSTSMaxAge = 365 * 24 * 60 * 60
+
+// Enabled сообщает, что сервер должен работать по HTTPS
+func Enabled(cfg moduls.TLS) bool {
+	return cfg.CertFile != "" || cfg.SelfSigned
+}
+
+// Config возвращает настройки TLS для сервера или nil, если HTTPS не настроен.
+// Самоподписанный сертификат создаётся при первом запуске.
+func Config(cfg moduls.TLS) (*tls.Config, error) {
+	if !Enabled(cfg) {
+		return nil, nil
+	}
+
+	var cert tls.Certificate
+	var err error
+	if cfg.SelfSigned {
+		cert, err = selfSigned(cfg)
+	} else {
+		cert, err = tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки сертификата: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// HSTS добавляет к ответам заголовок Strict-Transport-Security: браузер
+// запоминает, что к серверу можно обращаться только по HTTPS, и не отправит
+// токен открытым текстом, даже если пользователь наберёт адрес с http://
+func HSTS(cfg moduls.TLS) func(http.Handler) http.Handler {
+	if !cfg.HSTS {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	maxAge := DefaultHSTSMaxAge
+	if cfg.HSTSMaxAge > 0 {
+		maxAge = int(cfg.HSTSMaxAge.Seconds())
+	}
+	value := "max-age=" + strconv.Itoa(maxAge)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Strict-Transport-Security", value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Redirect перенаправляет запросы по HTTP на тот же адрес по HTTPS на порту port.
+// GET и HEAD получают 301, остальные методы — 308, чтобы клиент повторил
+// запрос с тем же методом и телом.
+func Redirect(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			// В заголовке Host нет порта
+			host = strings.Trim(r.Host, "[]")
+		}
+		if host == "" {
+			http.Error(w, "Host header required", http.StatusBadRequest)
+			return
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		target := "https://" + host + r.URL.RequestURI()
+
+		status := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		if r.Header.Get("Authorization") != "" {
+			slog.Warn("Токен авторизации получен по HTTP", "path", r.URL.Path)
+		}
+		http.Redirect(w, r, target, status)
+	})
+}
@@ -0,0 +1,131 @@
+package ics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"final-project/internal/utils"
+)
+
+// maxEventDays ограничивает длину многодневного события, чтобы ошибка
+// в DTEND не превратилась в тысячи дат
+const maxEventDays = 366
+
+// Event дата события календаря
+type Event struct {
+	Date    string
+	Summary string
+}
+
+// Parse читает календарь в формате iCalendar (RFC 5545) и возвращает даты
+// всех событий VEVENT. Многодневные события разворачиваются в отдельные даты.
+// Правила повтора (RRULE) не поддерживаются.
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events  []Event
+		inEvent bool
+		start   time.Time
+		end     time.Time
+		summary string
+	)
+	for i, line := range lines {
+		name, value, ok := splitProperty(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent = true
+			start, end, summary = time.Time{}, time.Time{}, ""
+		case name == "END" && value == "VEVENT":
+			if !inEvent {
+				return nil, fmt.Errorf("строка %d: END:VEVENT без BEGIN:VEVENT", i+1)
+			}
+			inEvent = false
+			if start.IsZero() {
+				return nil, fmt.Errorf("строка %d: событие без DTSTART", i+1)
+			}
+			// DTEND у событий на целый день не включается в событие
+			if end.IsZero() || !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			if end.Sub(start) > maxEventDays*24*time.Hour {
+				return nil, fmt.Errorf("строка %d: слишком длинное событие", i+1)
+			}
+			for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+				events = append(events, Event{Date: d.Format(utils.DateFormat), Summary: summary})
+			}
+		case inEvent && name == "DTSTART":
+			if start, err = parseDate(value); err != nil {
+				return nil, fmt.Errorf("строка %d: %w", i+1, err)
+			}
+		case inEvent && name == "DTEND":
+			if end, err = parseDate(value); err != nil {
+				return nil, fmt.Errorf("строка %d: %w", i+1, err)
+			}
+		case inEvent && name == "SUMMARY":
+			summary = unescape(value)
+		}
+	}
+	if inEvent {
+		return nil, fmt.Errorf("незавершённое событие VEVENT")
+	}
+	return events, nil
+}
+
+// unfold читает строки и склеивает перенесённые строки, начинающиеся с пробела или табуляции
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения календаря: %w", err)
+	}
+	return lines, nil
+}
+
+// splitProperty разбирает строку вида NAME;PARAM=VALUE:value на имя и значение
+func splitProperty(line string) (string, string, bool) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return "", "", false
+	}
+	name := line[:colon]
+	if semi := strings.Index(name, ";"); semi >= 0 {
+		name = name[:semi]
+	}
+	return strings.ToUpper(name), line[colon+1:], true
+}
+
+// parseDate разбирает значение DATE (20250101) или DATE-TIME (20250101T090000Z),
+// время при этом отбрасывается
+func parseDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("неверная дата %q", value)
+	}
+	date, err := time.Parse(utils.DateFormat, value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("неверная дата %q", value)
+	}
+	return date, nil
+}
+
+// unescape снимает экранирование текстовых значений iCalendar
+func unescape(value string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
@@ -0,0 +1,171 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"final-project/internal/apierror"
+	"final-project/internal/cache"
+)
+
+const (
+	// Header заголовок с ключом идемпотентности
+	Header = "Idempotency-Key"
+	// ReplayedHeader отмечает ответ, повторённый из сохранённого
+	ReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultTTL время хранения ответа по умолчанию
+	DefaultTTL = 24 * time.Hour
+
+	maxKeyLength = 255
+	maxBodySize  = 1 << 20
+)
+
+// replayHeaders заголовки ответа, которые сохраняются вместе с телом.
+// Остальные (например, X-Request-ID) относятся к конкретному запросу.
+var replayHeaders = []string{"Content-Type", "Location"}
+
+// entry запрос с ключом идемпотентности; пока запрос выполняется, done == false
+type entry struct {
+	fingerprint string
+	done        bool
+	status      int
+	header      http.Header
+	body        []byte
+}
+
+// Store хранит ответы на запросы с заголовком Idempotency-Key, чтобы повтор
+// запроса (например, после обрыва связи) не выполнял его второй раз
+type Store struct {
+	cache *cache.Cache
+	ttl   time.Duration
+}
+
+// NewStore создаёт хранилище ответов; ttl — сколько хранится ответ.
+// Устаревшие ответы удаляются в фоне до отмены ctx.
+func NewStore(ctx context.Context, ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Store{cache: cache.NewCache(ctx, "idempotency"), ttl: ttl}
+}
+
+// Middleware выполняет запрос с новым ключом и сохраняет ответ. Повтор с тем же
+// ключом и тем же запросом получает сохранённый ответ, с другим телом или
+// адресом — ошибку 409. Запросы без ключа выполняются как обычно.
+// Ответы 5xx не сохраняются, чтобы запрос можно было повторить.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			apierror.Write(w, r, apierror.Invalid(Header, apierror.FieldOutOfRange))
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeBadRequest).Wrap(err))
+			return
+		}
+		if len(body) > maxBodySize {
+			apierror.Write(w, r, apierror.Invalid("body", apierror.FieldOutOfRange))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		cacheKey := scope(r) + ":" + key
+		fp := fingerprint(r, body)
+
+		if !s.cache.Add(cacheKey, &entry{fingerprint: fp}, s.ttl) {
+			if value, ok := s.cache.Get(cacheKey); ok {
+				s.replay(w, r, value.(*entry), fp)
+				return
+			}
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			// Запрос не завершился или завершился ошибкой сервера: ключ освобождается
+			if !completed || rec.status >= http.StatusInternalServerError {
+				s.cache.Delete(cacheKey)
+				return
+			}
+			header := make(http.Header)
+			for _, name := range replayHeaders {
+				if value := rec.Header().Get(name); value != "" {
+					header.Set(name, value)
+				}
+			}
+			s.cache.Set(cacheKey, &entry{
+				fingerprint: fp,
+				done:        true,
+				status:      rec.status,
+				header:      header,
+				body:        rec.body.Bytes(),
+			}, s.ttl)
+		}()
+		next.ServeHTTP(rec, r)
+		completed = true
+	})
+}
+
+// replay отправляет сохранённый ответ или ошибку, если запрос не совпадает
+// с первым или ещё выполняется
+func (s *Store) replay(w http.ResponseWriter, r *http.Request, e *entry, fp string) {
+	switch {
+	case e.fingerprint != fp:
+		apierror.Write(w, r, apierror.New(http.StatusConflict, apierror.CodeIdempotencyKeyReused))
+	case !e.done:
+		apierror.Write(w, r, apierror.New(http.StatusConflict, apierror.CodeIdempotencyInProgress))
+	default:
+		for name, values := range e.header {
+			w.Header()[name] = values
+		}
+		w.Header().Set(ReplayedHeader, "true")
+		w.WriteHeader(e.status)
+		w.Write(e.body)
+	}
+}
+
+// scope отделяет ключи разных клиентов друг от друга
+func scope(r *http.Request) string {
+	sum := sha256.Sum256([]byte(r.Header.Get("Authorization")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// fingerprint отпечаток запроса: метод, адрес с параметрами и тело
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder передаёт ответ клиенту и одновременно запоминает его
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader запоминает статус ответа
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write запоминает тело ответа
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"final-project/internal/moduls"
+)
+
+// DefaultBodyMaxBytes сколько байт тела записывается по умолчанию
+const DefaultBodyMaxBytes = 2048
+
+// maskedFields поля JSON и форм, значения которых скрываются всегда
+var maskedFields = []string{"comment", "password", "token", "secret", "authorization"}
+
+// BodyCapture записывает в журнал тела запросов и ответов по настройкам moduls.BodyCapture
+type BodyCapture struct {
+	sampleRate float64
+	maxBytes   int
+	routes     map[string]float64
+	mask       *regexp.Regexp
+	formMask   *regexp.Regexp
+}
+
+// NewBodyCapture создаёт запись тел по настройкам; при выключенной записи возвращает nil
+func NewBodyCapture(cfg moduls.BodyCapture) *BodyCapture {
+	if !cfg.Enabled {
+		return nil
+	}
+	c := &BodyCapture{
+		sampleRate: cfg.SampleRate,
+		maxBytes:   cfg.MaxBytes,
+		routes:     cfg.Routes,
+	}
+	if c.sampleRate <= 0 {
+		c.sampleRate = 1
+	}
+	if c.maxBytes <= 0 {
+		c.maxBytes = DefaultBodyMaxBytes
+	}
+
+	fields := append(append([]string(nil), maskedFields...), cfg.Mask...)
+	for i, field := range fields {
+		fields[i] = regexp.QuoteMeta(field)
+	}
+	// "поле": "строка" или "поле": значение; строка может быть обрезана
+	c.mask = regexp.MustCompile(`(?i)("(?:` + strings.Join(fields, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.?)*"?|[^,}\]\s]*)`)
+	// поле=значение в теле формы application/x-www-form-urlencoded
+	c.formMask = regexp.MustCompile(`(?i)((?:^|&)(?:` + strings.Join(fields, "|") + `)=)[^&]*`)
+	return c
+}
+
+// rate доля записываемых запросов для пути: по самому длинному
+// подходящему префиксу из routes, иначе общая
+func (c *BodyCapture) rate(path string) float64 {
+	rate, longest := c.sampleRate, -1
+	for prefix, r := range c.routes {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			rate, longest = r, len(prefix)
+		}
+	}
+	return rate
+}
+
+// Start решает, записывать ли тела запроса r. Возвращает nil, если запись
+// выключена или запрос не попал в выборку: тогда тела не буферизуются.
+func (c *BodyCapture) Start(r *http.Request) *Capture {
+	if c == nil {
+		return nil
+	}
+	rate := c.rate(r.URL.Path)
+	if rate <= 0 || (rate < 1 && rand.Float64() >= rate) {
+		return nil
+	}
+	capture := &Capture{
+		owner:    c,
+		request:  capBuffer{max: c.maxBytes},
+		response: capBuffer{max: c.maxBytes},
+	}
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = &teeBody{ReadCloser: r.Body, buf: &capture.request}
+	}
+	return capture
+}
+
+// Capture тела одного запроса: запрос копируется по мере чтения обработчиком,
+// ответ — по мере записи
+type Capture struct {
+	owner    *BodyCapture
+	request  capBuffer
+	response capBuffer
+}
+
+// Response принимает копию тела ответа
+func (c *Capture) Response() io.Writer {
+	return &c.response
+}
+
+// Attrs возвращает атрибуты журнала с телами запроса и ответа
+func (c *Capture) Attrs(r *http.Request, header http.Header) []slog.Attr {
+	var attrs []slog.Attr
+	if body, ok := c.owner.format(&c.request, r.Header.Get("Content-Type")); ok {
+		attrs = append(attrs, slog.String("request_body", body))
+	}
+	if body, ok := c.owner.format(&c.response, header.Get("Content-Type")); ok {
+		attrs = append(attrs, slog.String("response_body", body))
+	}
+	return attrs
+}
+
+// format готовит тело к записи: скрывает поля JSON и форм и отмечает обрезку
+func (c *BodyCapture) format(b *capBuffer, contentType string) (string, bool) {
+	if b.total == 0 {
+		return "", false
+	}
+	// Клиенты не всегда указывают Content-Type: тогда тип определяется по содержимому,
+	// а тело, похожее на JSON, считается JSON
+	if contentType == "" {
+		contentType = http.DetectContentType(b.buf)
+	}
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	body := string(b.buf)
+	trimmed := strings.TrimSpace(body)
+	isJSON := strings.HasSuffix(mediaType, "json") || strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
+	if !isJSON && !strings.HasPrefix(mediaType, "text/") && mediaType != "application/x-www-form-urlencoded" {
+		return fmt.Sprintf("[%d bytes %s]", b.total, mediaType), true
+	}
+
+	switch {
+	case isJSON:
+		body = c.mask.ReplaceAllString(body, `${1}"`+Redacted+`"`)
+	case mediaType == "application/x-www-form-urlencoded":
+		body = c.formMask.ReplaceAllString(body, `${1}`+Redacted)
+	}
+	if b.total > len(b.buf) {
+		body += fmt.Sprintf("...[truncated %d bytes]", b.total-len(b.buf))
+	}
+	return body, true
+}
+
+// capBuffer хранит первые max байт записанных данных и считает общий размер
+type capBuffer struct {
+	max   int
+	buf   []byte
+	total int
+}
+
+// Write сохраняет данные, пока не заполнен буфер; остальное только считается
+func (b *capBuffer) Write(p []byte) (int, error) {
+	b.total += len(p)
+	if room := b.max - len(b.buf); room > 0 {
+		b.buf = append(b.buf, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// teeBody копирует прочитанное обработчиком тело запроса в буфер
+type teeBody struct {
+	io.ReadCloser
+	buf *capBuffer
+}
+
+// Read читает тело запроса и копирует прочитанное
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.buf.Write(p[:n])
+	return n, err
+}
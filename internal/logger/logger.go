@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"final-project/internal/moduls"
+)
+
+// Redacted заменяет значения скрытых атрибутов
+const Redacted = "[REDACTED]"
+
+// redactedKeys атрибуты, значения которых не попадают в журнал: токен
+// авторизации (заголовок и cookie) и комментарии к задачам, где могут быть личные данные
+var redactedKeys = []string{"authorization", "cookie", "comment"}
+
+// nopCloser закрывает ничего, когда журнал пишется в stderr
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// Setup создаёт журнал по настройкам и делает его журналом по умолчанию,
+// в том числе для стандартного пакета log. Возвращённый Closer закрывает файл журнала.
+func Setup(cfg moduls.Log) (*slog.Logger, io.Closer, error) {
+	var level slog.Level
+	if cfg.Level != "" {
+		if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+			return nil, nil, fmt.Errorf("неверный уровень журнала %q: %w", cfg.Level, err)
+		}
+	}
+
+	var out io.Writer = os.Stderr
+	var closer io.Closer = nopCloser{}
+	if cfg.File != "" {
+		file, err := OpenRotating(cfg.File, int64(cfg.MaxSizeMB)<<20, cfg.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = file, file
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redact}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(out, opts)
+	case "text":
+		handler = slog.NewTextHandler(out, opts)
+	default:
+		closer.Close()
+		return nil, nil, fmt.Errorf("неверный формат журнала %q: ожидается json или text", cfg.Format)
+	}
+
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+	return logger, closer, nil
+}
+
+// redact скрывает значения атрибутов из redactedKeys на любом уровне вложенности
+func redact(groups []string, a slog.Attr) slog.Attr {
+	for _, key := range redactedKeys {
+		if strings.EqualFold(a.Key, key) {
+			return slog.String(a.Key, Redacted)
+		}
+	}
+	return a
+}
+
+type contextKey struct{}
+
+// WithContext сохраняет журнал запроса в контексте
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext возвращает журнал запроса с его X-Request-ID
+// или журнал по умолчанию вне запроса
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
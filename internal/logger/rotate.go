@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSize размер файла журнала, после которого он ротируется
+const DefaultMaxSize = 100 << 20
+
+// rotateRetry через сколько повторить неудавшуюся ротацию
+const rotateRetry = time.Minute
+
+// RotatingFile файл журнала, который при достижении maxSize переименовывается
+// в path.1 (старые копии сдвигаются до path.<maxBackups>), а запись продолжается в новый файл
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	// rotateFailed время последней неудавшейся ротации
+	rotateFailed time.Time
+}
+
+// OpenRotating открывает файл журнала для дозаписи; maxSize <= 0 — DefaultMaxSize,
+// maxBackups <= 0 — старые копии не хранятся
+func OpenRotating(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	f := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write записывает строку журнала, при необходимости ротируя файл
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize && time.Since(f.rotateFailed) > rotateRetry {
+		if err := f.rotate(); err != nil {
+			// Журнал не может записать ошибку в самого себя; запись
+			// продолжается в прежний файл, ротация повторится позже
+			f.rotateFailed = time.Now()
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close закрывает файл журнала
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия файла журнала: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("ошибка открытия файла журнала: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// rotate сдвигает копии path.N -> path.N+1 и переименовывает текущий файл в path.1.
+// Текущий файл закрывается только после открытия нового, поэтому при ошибке
+// запись продолжается в него.
+func (f *RotatingFile) rotate() error {
+	if f.maxBackups > 0 {
+		for i := f.maxBackups - 1; i >= 1; i-- {
+			os.Rename(f.backup(i), f.backup(i+1))
+		}
+		if err := os.Rename(f.path, f.backup(1)); err != nil {
+			return fmt.Errorf("ошибка ротации журнала: %w", err)
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		return fmt.Errorf("ошибка ротации журнала: %w", err)
+	}
+	old := f.file
+	if err := f.open(); err != nil {
+		return fmt.Errorf("ошибка ротации журнала: %w", err)
+	}
+	old.Close()
+	return nil
+}
+
+func (f *RotatingFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", f.path, i)
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+// Границы корзин гистограмм в секундах
+var (
+	httpBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	dbBuckets   = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+)
+
+var (
+	httpRequests = NewCounterVec("http_requests_total",
+		"Total HTTP requests by route and status.", "method", "route", "status")
+	httpDuration = NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency in seconds.", httpBuckets, "method", "route", "status")
+	dbDuration = NewHistogramVec("db_query_duration_seconds",
+		"database.DB method latency in seconds.", dbBuckets, "method")
+	cacheRequests = NewCounterVec("cache_requests_total",
+		"Cache lookups by result (hit or miss).", "cache", "result")
+)
+
+// Middleware считает запросы и время их обработки. Маршрут берётся из шаблона
+// chi (/api/v2/tasks/{id}), а не из пути, чтобы число рядов не росло с числом задач.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		route := Route(r)
+		method := methodLabel(r)
+		status := strconv.Itoa(rec.status)
+		httpRequests.Inc(method, route, status)
+		httpDuration.Observe(time.Since(start).Seconds(), method, route, status)
+	})
+}
+
+// methodLabel возвращает метод запроса для метки: нестандартные методы
+// объединяются в "OTHER", иначе клиент мог бы создавать ряды без ограничения
+func methodLabel(r *http.Request) string {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return r.Method
+	}
+	return "OTHER"
+}
+
+// Route возвращает шаблон маршрута chi (/api/v2/tasks/{id}) обработанного запроса
+// или "unmatched", если маршрут не найден
+func Route(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.RoutePattern() == "" {
+		return "unmatched"
+	}
+	// Вложенные маршруты chi оставляют в шаблоне конечный слэш: /api/task/
+	route := rctx.RoutePattern()
+	if len(route) > 1 {
+		route = strings.TrimSuffix(route, "/")
+	}
+	return route
+}
+
+// ObserveQuery запоминает время выполнения метода базы данных, начатого в start
+func ObserveQuery(method string, start time.Time) {
+	dbDuration.Observe(time.Since(start).Seconds(), method)
+}
+
+// CacheHit отмечает попадание в кэш name
+func CacheHit(name string) {
+	cacheRequests.Inc(name, "hit")
+}
+
+// CacheMiss отмечает промах кэша name
+func CacheMiss(name string) {
+	cacheRequests.Inc(name, "miss")
+}
+
+// statusRecorder запоминает статус ответа
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader запоминает статус ответа
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,229 @@
+package metrics
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// collector выводит свои метрики в текстовом формате Prometheus
+type collector interface {
+	write(b *strings.Builder)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []collector
+)
+
+// register добавляет метрику в общий список; метрики выводятся в порядке регистрации
+func register(c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, c)
+}
+
+// Handler отдаёт все метрики в текстовом формате Prometheus (version 0.0.4)
+func Handler(w http.ResponseWriter, r *http.Request) {
+	registryMu.Lock()
+	collectors := append([]collector(nil), registry...)
+	registryMu.Unlock()
+
+	var b strings.Builder
+	for _, c := range collectors {
+		c.write(&b)
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+// desc имя, описание и метки метрики
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+// header выводит строки HELP и TYPE
+func (d desc) header(b *strings.Builder, kind string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, kind)
+}
+
+// key ключ ряда по значениям меток
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s ожидает %d меток, передано %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// CounterVec счётчик с метками
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*counter
+}
+
+type counter struct {
+	values []string
+	value  float64
+}
+
+// NewCounterVec создаёт и регистрирует счётчик
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name, help, labels}, series: make(map[string]*counter)}
+	register(c)
+	return c
+}
+
+// Inc увеличивает счётчик ряда с указанными значениями меток
+func (c *CounterVec) Inc(values ...string) {
+	key := c.key(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counter{values: values}
+		c.series[key] = s
+	}
+	s.value++
+}
+
+func (c *CounterVec) write(b *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(b, "counter")
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		fmt.Fprintf(b, "%s%s %s\n", c.name, labelString(c.labels, s.values), formatFloat(s.value))
+	}
+}
+
+// HistogramVec гистограмма с метками
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogram
+}
+
+type histogram struct {
+	values []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec создаёт и регистрирует гистограмму с верхними границами buckets
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{desc: desc{name, help, labels}, buckets: buckets, series: make(map[string]*histogram)}
+	register(h)
+	return h
+}
+
+// Observe добавляет наблюдение в ряд с указанными значениями меток
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := h.key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{values: values, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) write(b *strings.Builder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(b, "histogram")
+	labels := append(append([]string(nil), h.labels...), "le")
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		values := make([]string, len(labels))
+		copy(values, s.values)
+		for i, upper := range h.buckets {
+			values[len(values)-1] = formatFloat(upper)
+			fmt.Fprintf(b, "%s_bucket%s %d\n", h.name, labelString(labels, values), s.counts[i])
+		}
+		values[len(values)-1] = "+Inf"
+		fmt.Fprintf(b, "%s_bucket%s %d\n", h.name, labelString(labels, values), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", h.name, labelString(h.labels, s.values), formatFloat(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", h.name, labelString(h.labels, s.values), s.count)
+	}
+}
+
+// GaugeFunc датчик с одной меткой, значения которого вычисляются при каждом запросе метрик
+type GaugeFunc struct {
+	desc
+	fn func() (map[string]float64, error)
+}
+
+// NewGaugeFunc создаёт и регистрирует датчик; fn возвращает значения по значению метки label
+func NewGaugeFunc(name, help, label string, fn func() (map[string]float64, error)) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{name, help, []string{label}}, fn: fn}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(b *strings.Builder) {
+	values, err := g.fn()
+	if err != nil {
+		slog.Error("Ошибка вычисления метрики", "metric", g.name, "error", err)
+		return
+	}
+	g.header(b, "gauge")
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(b, "%s%s %s\n", g.name, labelString(g.labels, []string{key}), formatFloat(values[key]))
+	}
+}
+
+// labelString выводит метки в виде {name="value",...}
+func labelString(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
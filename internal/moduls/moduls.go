@@ -1,5 +1,11 @@
 package moduls
 
+import (
+	"log/slog"
+	"strconv"
+	"time"
+)
+
 // Scheduler структура для хранения информации о задаче.
 type Scheduler struct {
 	ID      string `json:"id"`
@@ -7,6 +13,164 @@ type Scheduler struct {
 	Title   string `json:"title"`
 	Comment string `json:"comment"`
 	Repeat  string `json:"repeat"`
+	// Until дата, после которой повтор прекращается (необязательно)
+	Until string `json:"until,omitempty"`
+	// Count оставшееся количество повторений, 0 — без ограничений
+	Count int `json:"count,omitempty"`
+	// Anchor исходная дата отложенного повторения, от которой считается следующая дата
+	Anchor string `json:"anchor,omitempty"`
+}
+
+// LogValue представляет задачу в журнале отдельными полями, чтобы
+// журнал мог скрыть комментарий
+func (s Scheduler) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", s.ID),
+		slog.String("date", s.Date),
+		slog.String("title", s.Title),
+		slog.String("comment", s.Comment),
+		slog.String("repeat", s.Repeat),
+	)
+}
+
+// TaskDetails структура задачи вместе с её зависимостями
+type TaskDetails struct {
+	Scheduler
+	BlockedBy []Scheduler `json:"blocked_by,omitempty"`
+	Blocks    []Scheduler `json:"blocks,omitempty"`
+}
+
+// Calendar общий календарь дат-исключений (например, праздников)
+type Calendar struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Dates int    `json:"dates"`
+}
+
+// TaskExceptions даты-исключения задачи и подключённые к ней календари
+type TaskExceptions struct {
+	Dates     []string   `json:"dates"`
+	Calendars []Calendar `json:"calendars"`
+}
+
+// NextDatePreview ближайшие даты повтора и описание правила
+type NextDatePreview struct {
+	Dates       []string `json:"dates"`
+	Description string   `json:"description"`
+	// Ended показывает, что серия повторов закончилась раньше, чем набралось нужное количество дат
+	Ended bool `json:"ended,omitempty"`
+}
+
+// TaskV2 задача в API v2. В отличие от Scheduler, идентификатор — число.
+type TaskV2 struct {
+	ID      int64  `json:"id"`
+	Date    string `json:"date"`
+	Title   string `json:"title"`
+	Comment string `json:"comment"`
+	Repeat  string `json:"repeat"`
+	Until   string `json:"until,omitempty"`
+	Count   int    `json:"count,omitempty"`
+	Anchor  string `json:"anchor,omitempty"`
+}
+
+// NewTaskV2 преобразует задачу в представление API v2
+func NewTaskV2(task Scheduler) TaskV2 {
+	id, _ := strconv.ParseInt(task.ID, 10, 64)
+	return TaskV2{
+		ID:      id,
+		Date:    task.Date,
+		Title:   task.Title,
+		Comment: task.Comment,
+		Repeat:  task.Repeat,
+		Until:   task.Until,
+		Count:   task.Count,
+		Anchor:  task.Anchor,
+	}
+}
+
+// NewTasksV2 преобразует список задач в представление API v2
+func NewTasksV2(tasks []Scheduler) []TaskV2 {
+	result := make([]TaskV2, 0, len(tasks))
+	for _, task := range tasks {
+		result = append(result, NewTaskV2(task))
+	}
+	return result
+}
+
+// Scheduler преобразует задачу API v2 в Scheduler
+func (t TaskV2) Scheduler() Scheduler {
+	id := ""
+	if t.ID != 0 {
+		id = strconv.FormatInt(t.ID, 10)
+	}
+	return Scheduler{
+		ID:      id,
+		Date:    t.Date,
+		Title:   t.Title,
+		Comment: t.Comment,
+		Repeat:  t.Repeat,
+		Until:   t.Until,
+		Count:   t.Count,
+		Anchor:  t.Anchor,
+	}
+}
+
+// TaskV2Details задача API v2 вместе с её зависимостями
+type TaskV2Details struct {
+	TaskV2
+	BlockedBy []TaskV2 `json:"blocked_by,omitempty"`
+	Blocks    []TaskV2 `json:"blocks,omitempty"`
+}
+
+// TaskV2List список задач API v2
+type TaskV2List struct {
+	Tasks []TaskV2 `json:"tasks"`
+}
+
+// QuickTaskRequest запрос на быстрое добавление задачи фразой
+type QuickTaskRequest struct {
+	Text string `json:"text"`
+	// Create создаёт задачу сразу, иначе только возвращает результат разбора
+	Create bool `json:"create"`
+}
+
+// QuickTask результат разбора фразы быстрого добавления
+type QuickTask struct {
+	ID   int       `json:"id,omitempty"`
+	Task Scheduler `json:"task"`
+}
+
+// AuditEntry запись журнала аудита: кто, в каком запросе и как изменил задачу.
+// Before отсутствует у созданной задачи, After — у удалённой.
+type AuditEntry struct {
+	ID        int64      `json:"id"`
+	Time      string     `json:"time"`
+	Actor     string     `json:"actor"`
+	RequestID string     `json:"request_id,omitempty"`
+	Action    string     `json:"action"`
+	TaskID    string     `json:"task_id"`
+	Before    *Scheduler `json:"before,omitempty"`
+	After     *Scheduler `json:"after,omitempty"`
+}
+
+// Settings настройки пользователя
+type Settings struct {
+	// Timezone часовой пояс пользователя (имя IANA), пустой — часовой пояс экземпляра
+	Timezone string `json:"timezone"`
+	// Effective часовой пояс, в котором выполнен запрос
+	Effective string `json:"effective,omitempty"`
+}
+
+// AuditFilter условия выборки журнала аудита; пустые поля не ограничивают выборку
+type AuditFilter struct {
+	TaskID string
+	Actor  string
+	Action string
+	// From и To границы времени изменения, To не включается
+	From time.Time
+	To   time.Time
+	// Limit наибольшее число записей, 0 — без ограничения
+	Limit int
 }
 
 // структура для id задачи
@@ -23,11 +187,119 @@ type Errors struct {
 type Config struct {
 	Port   string `json:"port"`
 	DBFile string `json:"db_file"`
+	// Timezone часовой пояс экземпляра по умолчанию (имя IANA), пустой — локальный
+	Timezone string         `json:"timezone"`
+	Location *time.Location `json:"-"`
+	// DebugClock разрешает подменять текущее время заголовком X-Debug-Now
+	DebugClock bool `json:"debug_clock"`
+	// DebugNow фиксированное текущее время экземпляра для воспроизводимых сценариев
+	DebugNow string `json:"debug_now"`
+	// IdempotencyTTL сколько хранится ответ на запрос с заголовком Idempotency-Key
+	IdempotencyTTL time.Duration `json:"idempotency_ttl"`
+	// DBQueryTimeout время на один запрос к базе данных; 0 — значение по умолчанию
+	DBQueryTimeout time.Duration `json:"db_query_timeout"`
+	// RateLimit ограничение частоты запросов к API
+	RateLimit RateLimit `json:"rate_limit"`
+	// MetricsAddr отдельный адрес для /metrics без авторизации, например
+	// 127.0.0.1:9090; пустой — /metrics на основном порту с авторизацией
+	MetricsAddr string `json:"metrics_addr"`
+	// Log настройки журнала
+	Log Log `json:"log"`
+	// Tracing настройки трассировки OpenTelemetry
+	Tracing Tracing `json:"tracing"`
+	// HTTP тайм-ауты HTTP-сервера
+	HTTP HTTP `json:"http"`
+	// TLS настройки HTTPS
+	TLS TLS `json:"tls"`
 	// JWTSecret string `json:"jwt_secret"`
 	// Password string `json:"password"`
 	// TestEnv  string `json:"test_env"`
 }
 
+// HTTP тайм-ауты HTTP-сервера; нулевые значения заменяются значениями по умолчанию
+type HTTP struct {
+	// ReadTimeout время на чтение запроса вместе с телом
+	ReadTimeout time.Duration `json:"read_timeout"`
+	// WriteTimeout время на обработку запроса и запись ответа
+	WriteTimeout time.Duration `json:"write_timeout"`
+	// IdleTimeout сколько ждать следующего запроса в keep-alive соединении
+	IdleTimeout time.Duration `json:"idle_timeout"`
+	// ShutdownTimeout сколько ждать завершения начатых запросов при остановке
+	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
+}
+
+// TLS настройки HTTPS. Сервер работает по HTTPS, если задан сертификат
+// или включён самоподписанный сертификат.
+type TLS struct {
+	// CertFile и KeyFile файлы сертификата и закрытого ключа в формате PEM
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// SelfSigned создаёт самоподписанный сертификат для разработки. Если заданы
+	// CertFile и KeyFile, сертификат сохраняется в них и используется повторно.
+	SelfSigned bool `json:"self_signed"`
+	// Hosts имена и IP-адреса в самоподписанном сертификате; пустой — localhost и имя машины
+	Hosts []string `json:"hosts"`
+	// RedirectPort порт, на котором запросы по HTTP перенаправляются на HTTPS; пустой — не слушать
+	RedirectPort string `json:"redirect_port"`
+	// HSTS отправлять заголовок Strict-Transport-Security
+	HSTS bool `json:"hsts"`
+	// HSTSMaxAge сколько браузер должен обращаться к серверу только по HTTPS
+	HSTSMaxAge time.Duration `json:"hsts_max_age"`
+}
+
+// Tracing настройки трассировки OpenTelemetry
+type Tracing struct {
+	// Exporter куда отправлять spans: none, stdout или otlp
+	Exporter string `json:"exporter"`
+	// Endpoint адрес OTLP/HTTP коллектора (host:port); пустой — из OTEL_EXPORTER_OTLP_ENDPOINT
+	Endpoint string `json:"endpoint"`
+	// SampleRatio доля записываемых трасс от 0 до 1
+	SampleRatio float64 `json:"sample_ratio"`
+	// ServiceName имя сервиса в трассах
+	ServiceName string `json:"service_name"`
+}
+
+// Log настройки журнала
+type Log struct {
+	// Level минимальный уровень: debug, info, warn или error
+	Level string `json:"level"`
+	// Format формат записей: json или text
+	Format string `json:"format"`
+	// File файл журнала; пустой — stderr
+	File string `json:"file"`
+	// MaxSizeMB размер файла в мегабайтах, после которого он ротируется
+	MaxSizeMB int `json:"max_size_mb"`
+	// MaxBackups сколько старых файлов журнала хранить
+	MaxBackups int `json:"max_backups"`
+	// Body запись тел запросов и ответов
+	Body BodyCapture `json:"body"`
+}
+
+// BodyCapture настройки записи тел запросов и ответов в журнал
+type BodyCapture struct {
+	Enabled bool `json:"enabled"`
+	// SampleRate доля записываемых запросов от 0 до 1
+	SampleRate float64 `json:"sample_rate"`
+	// MaxBytes сколько байт каждого тела записывать, остальное обрезается
+	MaxBytes int `json:"max_bytes"`
+	// Routes доли записываемых запросов по префиксу пути; 0 отключает запись
+	Routes map[string]float64 `json:"routes"`
+	// Mask поля JSON и форм, значения которых скрываются, в дополнение к стандартным
+	Mask []string `json:"mask"`
+}
+
+// RateLimit настройки ограничения частоты запросов одного клиента;
+// нулевые значения заменяются значениями по умолчанию
+type RateLimit struct {
+	Enabled bool `json:"enabled"`
+	// ReadRate запросов на чтение в секунду, ReadBurst — сколько можно сделать подряд
+	ReadRate  float64 `json:"read_rate"`
+	ReadBurst int     `json:"read_burst"`
+	// WriteRate и WriteBurst то же для изменяющих запросов
+	WriteRate  float64 `json:"write_rate"`
+	WriteBurst int     `json:"write_burst"`
+}
+
 // структура для списка задач
 type SchedulerList struct {
 	Tasks []Scheduler `json:"tasks"`
@@ -0,0 +1,219 @@
+package nextdate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Describe возвращает описание правила повтора на английском языке,
+// например "every 2nd and 15th of March and June".
+// Правило должно быть предварительно проверено через NextDate.
+func Describe(repeat string) (string, error) {
+	fields := strings.Fields(repeat)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("правило повтора не указано")
+	}
+	args := fields[1:]
+
+	switch fields[0] {
+	case "d":
+		if len(args) != 1 {
+			break
+		}
+		return describeInterval(args[0], "day")
+	case "b":
+		if len(args) != 1 {
+			break
+		}
+		return describeInterval(args[0], "business day")
+	case "y":
+		return describeYearly(args)
+	case "w":
+		return describeWeekly(args)
+	case "m":
+		return describeMonthly(args)
+	case "mw":
+		return describeNthWeekday(args)
+	}
+	return "", fmt.Errorf("неверный формат повтора")
+}
+
+// describeInterval описывает повтор через N дней или рабочих дней
+func describeInterval(value, unit string) (string, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return "", fmt.Errorf("неверный формат повтора")
+	}
+	if n == 1 {
+		return "every " + unit, nil
+	}
+	return fmt.Sprintf("every %d %ss", n, unit), nil
+}
+
+// describeYearly описывает правило "y [MMDD,...] [feb28|mar1]"
+func describeYearly(args []string) (string, error) {
+	var dates []string
+	leapDay := ""
+	for _, arg := range args {
+		switch arg {
+		case leapDayFeb28:
+			leapDay = "28 February"
+		case leapDayMar1:
+			leapDay = "1 March"
+		default:
+			for _, md := range strings.Split(arg, ",") {
+				date, err := time.Parse("20060102", "2000"+md)
+				if err != nil {
+					return "", fmt.Errorf("неверная дата в 'y' формате повтора: %s", md)
+				}
+				dates = append(dates, date.Format("January 2"))
+			}
+		}
+	}
+
+	description := "every year on the same date"
+	if len(dates) > 0 {
+		description = "every year on " + joinList(dates)
+	}
+	if leapDay != "" {
+		description += ", 29 February falls on " + leapDay + " in non-leap years"
+	}
+	return description, nil
+}
+
+// describeWeekly описывает правило "w D,... [/N]"
+func describeWeekly(args []string) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", fmt.Errorf("неверный 'w' формат повтора")
+	}
+	days, err := weekdayNames(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	prefix := "every "
+	if len(args) == 2 {
+		n, err := strconv.Atoi(strings.TrimPrefix(args[1], "/"))
+		if err != nil || n < 1 {
+			return "", fmt.Errorf("неверный интервал недель: %s", args[1])
+		}
+		switch n {
+		case 1:
+		case 2:
+			prefix = "every other week on "
+		default:
+			prefix = fmt.Sprintf("every %s week on ", ordinal(n))
+		}
+	}
+	return prefix + joinList(days), nil
+}
+
+// describeMonthly описывает правило "m D,... [M,...]"
+func describeMonthly(args []string) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", fmt.Errorf("неверный 'm' формат повтора")
+	}
+	var days []string
+	for _, d := range strings.Split(args[0], ",") {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return "", fmt.Errorf("неверный день месяца: %s", d)
+		}
+		switch n {
+		case -1:
+			days = append(days, "last day")
+		case -2:
+			days = append(days, "second to last day")
+		default:
+			days = append(days, ordinal(n))
+		}
+	}
+	return describeMonths(joinList(days), args[1:])
+}
+
+// describeNthWeekday описывает правило "mw N-D,... [M,...]"
+func describeNthWeekday(args []string) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", fmt.Errorf("неверный 'mw' формат повтора")
+	}
+	weekdays, err := parsNthWeekdays(args[0])
+	if err != nil {
+		return "", err
+	}
+	var items []string
+	for _, wd := range weekdays {
+		var n string
+		switch wd.n {
+		case -1:
+			n = "last"
+		case -2:
+			n = "second to last"
+		default:
+			n = ordinal(wd.n)
+		}
+		items = append(items, n+" "+weekdayName(wd.weekday))
+	}
+	return describeMonths(joinList(items), args[1:])
+}
+
+// describeMonths добавляет к описанию дней список месяцев
+func describeMonths(days string, months []string) (string, error) {
+	if len(months) == 0 {
+		return "every month on the " + days, nil
+	}
+	var names []string
+	for _, m := range strings.Split(months[0], ",") {
+		n, err := strconv.Atoi(m)
+		if err != nil || n < 1 || n > 12 {
+			return "", fmt.Errorf("неверный месяц: %s", m)
+		}
+		names = append(names, time.Month(n).String())
+	}
+	return "every " + days + " of " + joinList(names), nil
+}
+
+// weekdayNames возвращает названия дней недели из списка номеров
+func weekdayNames(list string) ([]string, error) {
+	var names []string
+	for _, d := range strings.Split(list, ",") {
+		n, err := strconv.Atoi(d)
+		if err != nil || n < 1 || n > 7 {
+			return nil, fmt.Errorf("неверный день недели: %s", d)
+		}
+		names = append(names, weekdayName(n))
+	}
+	return names, nil
+}
+
+// weekdayName возвращает название дня недели по номеру от 1 (понедельник) до 7
+func weekdayName(n int) string {
+	return time.Weekday(n % 7).String()
+}
+
+// ordinal возвращает порядковое числительное: 1st, 2nd, 3rd, 11th, 22nd
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// joinList объединяет элементы в перечисление: "a", "a and b", "a, b and c"
+func joinList(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
@@ -4,20 +4,87 @@ import (
 	"errors"
 	"final-project/internal/utils"
 	"fmt"
-	"log"
-	"sort"
+	"log/slog"
 	"strconv"
 	"strings"
 	"time"
 )
 
+var (
+	// ErrSeriesEnded возвращается, если у повторяющейся задачи не осталось повторений
+	ErrSeriesEnded = errors.New("повторения задачи закончились")
+	// ErrInvalidDate возвращается при неверном формате исходной даты
+	ErrInvalidDate = errors.New("неверный формат даты")
+	// ErrInvalidCount возвращается при отрицательном количестве повторений
+	ErrInvalidCount = errors.New("неверное количество повторений")
+	// ErrInvalidUntil возвращается при неверном формате даты окончания повтора
+	ErrInvalidUntil = errors.New("неверный формат даты окончания повтора")
+	// ErrAllExcluded возвращается, если все ближайшие даты повтора исключены
+	ErrAllExcluded = errors.New("все ближайшие даты повтора исключены")
+)
+
+// Options задаёт необязательные условия окончания повтора.
+type Options struct {
+	// Until дата в формате utils.DateFormat, после которой повторений нет
+	Until string
+	// Count оставшееся количество повторений, включая текущее; 0 — без ограничений
+	Count int
+	// Exceptions даты в формате utils.DateFormat, на которые повторение не выпадает
+	Exceptions map[string]bool
+}
+
+// maxExceptionSkips ограничивает количество подряд пропущенных дат-исключений
+const maxExceptionSkips = 1000
+
+// NextDateWithOptions вычисляет следующую дату с учётом условий окончания повтора.
+// Если следующего повторения нет, возвращается ErrSeriesEnded.
+func NextDateWithOptions(now time.Time, date string, repeat string, opts Options) (string, error) {
+	if opts.Count < 0 {
+		return "", ErrInvalidCount
+	}
+	var until string
+	if opts.Until != "" {
+		untilTime, err := time.Parse(utils.DateFormat, opts.Until)
+		if err != nil {
+			return "", ErrInvalidUntil
+		}
+		until = untilTime.Format(utils.DateFormat)
+	}
+
+	next, err := NextDate(now, date, repeat)
+	if err != nil {
+		return "", err
+	}
+
+	// Пропускаем даты-исключения: следующая дата ищется после исключённой
+	for i := 0; opts.Exceptions[next]; i++ {
+		if i >= maxExceptionSkips {
+			return "", ErrAllExcluded
+		}
+		skipped, _ := time.Parse(utils.DateFormat, next)
+		if next, err = NextDate(skipped, next, repeat); err != nil {
+			return "", err
+		}
+	}
+
+	// Текущее повторение было последним
+	if opts.Count == 1 {
+		return "", ErrSeriesEnded
+	}
+	// Следующая дата выходит за дату окончания
+	if until != "" && next > until {
+		return "", ErrSeriesEnded
+	}
+	return next, nil
+}
+
 // NextDate вычисляет следующую дату, основываясь на текущей дате, заданной дате и правиле повтора.
 func NextDate(now time.Time, date string, repeat string) (string, error) {
-	log.Printf("NextDate вызван с параметрами: now=%v, date=%s, repeat=%s", now, date, repeat)
+	slog.Debug("Вычисление следующей даты", "now", now.Format(utils.DateFormat), "date", date, "repeat", repeat)
 	// Парсим строку с датой в объект времени
 	dateTime, err := time.Parse(utils.DateFormat, date)
 	if err != nil {
-		return "", fmt.Errorf("неверный формат даты")
+		return "", ErrInvalidDate
 	}
 	// Если дата задачи уже в будущем, возвращаем её без изменений
 	//if dateTime.After(now) {
@@ -50,9 +117,17 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 				break
 			}
 		}
+	case strings.HasPrefix(repeat, "y "):
+		// Если повторение ежегодно в указанные даты
+		return calculateNextDateYearly(now, dateTime, strings.Fields(strings.TrimPrefix(repeat, "y ")))
+
 	case strings.HasPrefix(repeat, "w "):
-		// Если повторение через определенные дни недели
-		repeatDaysStr := strings.Split(strings.TrimPrefix(repeat, "w "), ",")
+		// Если повторение через определенные дни недели, при необходимости раз в несколько недель
+		format := strings.Fields(strings.TrimPrefix(repeat, "w "))
+		if len(format) == 0 || len(format) > 2 {
+			return "", fmt.Errorf("неверный 'w' формат повтора")
+		}
+		repeatDaysStr := strings.Split(format[0], ",")
 		repeatDays := make([]int, 0, len(repeatDaysStr))
 		for _, day := range repeatDaysStr {
 			dayNumber, err := strconv.Atoi(day)
@@ -62,8 +137,17 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 			repeatDays = append(repeatDays, dayNumber)
 		}
 
+		// Интервал в неделях задаётся как /N
+		interval := 1
+		if len(format) == 2 {
+			interval, err = strconv.Atoi(strings.TrimPrefix(format[1], "/"))
+			if !strings.HasPrefix(format[1], "/") || err != nil || interval < 1 || interval > 52 {
+				return "", fmt.Errorf("неверный интервал недель: %s", format[1])
+			}
+		}
+
 		// Вычисляем следующую дату
-		nextDate, err := calculateNextDateWeekly(now, dateTime, repeatDays)
+		nextDate, err := calculateNextDateWeekly(now, dateTime, repeatDays, interval)
 		if err != nil {
 			return "", err
 		}
@@ -104,6 +188,35 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 			}
 		}
 
+	case strings.HasPrefix(repeat, "mw "):
+		// Если повторение в n-й день недели месяца
+		format := strings.Split(strings.TrimPrefix(repeat, "mw "), " ")
+		if len(format) > 2 {
+			return "", fmt.Errorf("неверный 'mw' формат повтора")
+		}
+		weekdays, err := parsNthWeekdays(format[0])
+		if err != nil {
+			return "", err
+		}
+		allowMonths, err := parsMonth(format)
+		if err != nil || len(allowMonths) == 0 {
+			return "", fmt.Errorf("неверный 'mw' формат повтора")
+		}
+		return calculateNextDateNthWeekday(now, dateTime, weekdays, allowMonths)
+
+	case strings.HasPrefix(repeat, "b "):
+		// Если повторение через определенное количество рабочих дней
+		days, err := strconv.Atoi(strings.TrimPrefix(repeat, "b "))
+		if err != nil || days < 1 || days > 400 {
+			return "", fmt.Errorf("неверный 'b' формат повтора")
+		}
+		for {
+			dateTime = addBusinessDays(dateTime, days)
+			if dateTime.After(now) {
+				break
+			}
+		}
+
 	default:
 		return "", fmt.Errorf("неверный формат повтора")
 	}
@@ -112,32 +225,33 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 }
 
 // calculateNextDateWeekly вычисляет следующую дату для еженедельного повтора.
-func calculateNextDateWeekly(now, start time.Time, days []int) (string, error) {
+// При интервале больше одной недели подходят только недели, отстоящие
+// от недели начальной даты на кратное интервалу количество недель.
+func calculateNextDateWeekly(now, start time.Time, days []int, interval int) (string, error) {
 	if len(days) == 0 {
 		return "", fmt.Errorf("не указаны дни недели для повтора")
 	}
-	sort.Ints(days)
+
+	// Понедельник недели, от которой отсчитываются интервалы
+	anchor := start.AddDate(0, 0, 1-weekdayNumber(start))
 
 	next := start
 	if next.Before(now) {
 		next = now
 	}
+	next = time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, time.UTC)
 
-	for {
-		nowWeekday := int(next.Weekday())
-		if nowWeekday == 0 {
-			nowWeekday = 7
+	// За interval+1 недель подходящий день гарантированно встретится
+	for i := 0; i < 7*(interval+1); i, next = i+1, next.AddDate(0, 0, 1) {
+		if !next.After(now) || !isSliceHas(days, weekdayNumber(next)) {
+			continue
 		}
-
-		for _, day := range days {
-			daysUntil := (day - nowWeekday + 7) % 7
-			candidateDate := next.AddDate(0, 0, daysUntil)
-			if candidateDate.After(now) {
-				return candidateDate.Format(utils.DateFormat), nil
-			}
+		weeks := int(next.Sub(anchor).Hours()/24) / 7
+		if weeks%interval == 0 {
+			return next.Format(utils.DateFormat), nil
 		}
-		next = next.AddDate(0, 0, 1)
 	}
+	return "", fmt.Errorf("не найдена дата для 'w' повтора")
 }
 
 // calculateNextDateMonthly вычисляет следующую дату для ежемесячного повтора.
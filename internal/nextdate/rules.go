@@ -0,0 +1,214 @@
+package nextdate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"final-project/internal/utils"
+)
+
+// maxMonthsSearch ограничивает поиск n-го дня недели: пятая пятница
+// февраля, например, встречается раз в несколько десятилетий
+const maxMonthsSearch = 12 * 400
+
+// nthWeekday n-й день недели месяца: n от 1 до 5 или -1 и -2 для
+// последнего и предпоследнего, weekday от 1 (понедельник) до 7 (воскресенье)
+type nthWeekday struct {
+	n       int
+	weekday int
+}
+
+// parsNthWeekdays разбирает список вида "2-2,-1-5" для правила mw
+func parsNthWeekdays(format string) ([]nthWeekday, error) {
+	items := strings.Split(format, ",")
+	result := make([]nthWeekday, 0, len(items))
+	for _, item := range items {
+		// Номер может быть отрицательным, поэтому делим по последнему дефису
+		sep := strings.LastIndex(item, "-")
+		if sep <= 0 {
+			return nil, fmt.Errorf("неверный 'mw' формат повтора: %s", item)
+		}
+		n, err := strconv.Atoi(item[:sep])
+		if err != nil || n == 0 || n < -2 || n > 5 {
+			return nil, fmt.Errorf("неверный номер дня недели: %s", item)
+		}
+		weekday, err := strconv.Atoi(item[sep+1:])
+		if err != nil || weekday < 1 || weekday > 7 {
+			return nil, fmt.Errorf("неверный день недели: %s", item)
+		}
+		result = append(result, nthWeekday{n: n, weekday: weekday})
+	}
+	return result, nil
+}
+
+// calculateNextDateNthWeekday вычисляет следующую дату для повтора в n-й день недели месяца.
+// Дата не может быть раньше start и должна быть позже now.
+func calculateNextDateNthWeekday(now, start time.Time, weekdays []nthWeekday, months []int) (string, error) {
+	month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < maxMonthsSearch; i, month = i+1, month.AddDate(0, 1, 0) {
+		if !isSliceHas(months, int(month.Month())) {
+			continue
+		}
+
+		// Выбираем ближайшую подходящую дату в месяце
+		var nearest time.Time
+		for _, wd := range weekdays {
+			candidate, ok := nthWeekdayOfMonth(month, wd)
+			if !ok || candidate.Before(start) || !candidate.After(now) {
+				continue
+			}
+			if nearest.IsZero() || candidate.Before(nearest) {
+				nearest = candidate
+			}
+		}
+		if !nearest.IsZero() {
+			return nearest.Format(utils.DateFormat), nil
+		}
+	}
+	return "", fmt.Errorf("не найдена дата для 'mw' повтора")
+}
+
+// nthWeekdayOfMonth возвращает n-й день недели месяца, начинающегося с first.
+// Если такого дня в месяце нет (например, пятого вторника), возвращает false.
+func nthWeekdayOfMonth(first time.Time, wd nthWeekday) (time.Time, bool) {
+	days := daysInsert(first.Month(), first.Year())
+	if wd.n > 0 {
+		firstWeekday := weekdayNumber(first)
+		day := 1 + (wd.weekday-firstWeekday+7)%7 + (wd.n-1)*7
+		if day > days {
+			return time.Time{}, false
+		}
+		return first.AddDate(0, 0, day-1), true
+	}
+
+	last := first.AddDate(0, 0, days-1)
+	lastWeekday := weekdayNumber(last)
+	day := days - (lastWeekday-wd.weekday+7)%7 + (wd.n+1)*7
+	return first.AddDate(0, 0, day-1), true
+}
+
+// addBusinessDays прибавляет к дате указанное количество рабочих дней (понедельник — пятница)
+func addBusinessDays(date time.Time, days int) time.Time {
+	for days > 0 {
+		date = date.AddDate(0, 0, 1)
+		if weekdayNumber(date) <= 5 {
+			days--
+		}
+	}
+	return date
+}
+
+// weekdayNumber возвращает номер дня недели от 1 (понедельник) до 7 (воскресенье)
+func weekdayNumber(date time.Time) int {
+	weekday := int(date.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return weekday
+}
+
+// Варианты переноса 29 февраля в невисокосные годы
+const (
+	leapDayFeb28 = "feb28"
+	leapDayMar1  = "mar1"
+)
+
+// calculateNextDateYearly вычисляет следующую дату для правила "y MMDD,... [feb28|mar1]".
+// Если даты не указаны, используется день и месяц начальной даты, а следующая дата
+// должна быть позже неё, как у правила "y". Вариант feb28 или mar1 задаёт дату
+// вместо 29 февраля в невисокосные годы; по умолчанию используется 1 марта.
+func calculateNextDateYearly(now, start time.Time, format []string) (string, error) {
+	if len(format) == 0 {
+		return "", fmt.Errorf("неверный 'y' формат повтора")
+	}
+	var dates []string
+	policy := ""
+	for _, field := range format {
+		switch {
+		case field == leapDayFeb28 || field == leapDayMar1:
+			if policy != "" {
+				return "", fmt.Errorf("неверный 'y' формат повтора")
+			}
+			policy = field
+		case dates == nil:
+			dates = strings.Split(field, ",")
+			for _, d := range dates {
+				if !isValidMonthDay(d) {
+					return "", fmt.Errorf("неверная дата в 'y' формате повтора: %s", d)
+				}
+			}
+		default:
+			return "", fmt.Errorf("неверный 'y' формат повтора")
+		}
+	}
+	if policy == "" {
+		policy = leapDayMar1
+	}
+
+	after := now
+	if dates == nil {
+		// Дата, перенесённая с 29 февраля по выбранному варианту,
+		// снова становится 29 февраля в високосный год
+		monthDay := start.Format("0102")
+		if !isLeap(start.Year()) &&
+			(policy == leapDayFeb28 && monthDay == "0228" || policy == leapDayMar1 && monthDay == "0301") {
+			monthDay = "0229"
+		}
+		dates = []string{monthDay}
+		if start.After(after) {
+			after = start
+		}
+	}
+
+	year := start.Year()
+	if now.Year() > year {
+		year = now.Year()
+	}
+	// Ближайшая дата гарантированно найдётся в течение двух лет
+	for last := year + 2; year <= last; year++ {
+		var nearest time.Time
+		for _, d := range dates {
+			candidate := resolveMonthDay(year, d, policy)
+			if candidate.Before(start) || !candidate.After(after) {
+				continue
+			}
+			if nearest.IsZero() || candidate.Before(nearest) {
+				nearest = candidate
+			}
+		}
+		if !nearest.IsZero() {
+			return nearest.Format(utils.DateFormat), nil
+		}
+	}
+	return "", fmt.Errorf("не найдена дата для 'y' повтора")
+}
+
+// isValidMonthDay проверяет дату вида MMDD; 29 февраля допускается
+func isValidMonthDay(s string) bool {
+	if len(s) != 4 {
+		return false
+	}
+	// 2000 год високосный, поэтому 0229 считается допустимой датой
+	_, err := time.Parse("20060102", "2000"+s)
+	return err == nil
+}
+
+// resolveMonthDay возвращает дату MMDD в указанном году, перенося
+// 29 февраля в невисокосный год по выбранному варианту
+func resolveMonthDay(year int, monthDay string, policy string) time.Time {
+	if monthDay == "0229" && !isLeap(year) {
+		if policy == leapDayFeb28 {
+			return time.Date(year, time.February, 28, 0, 0, 0, 0, time.UTC)
+		}
+		return time.Date(year, time.March, 1, 0, 0, 0, 0, time.UTC)
+	}
+	date, _ := time.Parse("20060102", fmt.Sprintf("%04d%s", year, monthDay))
+	return date
+}
+
+// isLeap проверяет, является ли год високосным
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
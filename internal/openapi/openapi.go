@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+//go:embed docs.html
+var docs []byte
+
+// docsPolicy разрешает странице документации только встроенные скрипты
+// и стили и запросы к своему адресу
+const docsPolicy = "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'"
+
+// Document разобранный документ OpenAPI 3. Описаны только поля,
+// нужные для проверки запросов.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+// PathItem операции одного пути
+type PathItem struct {
+	Get    *Operation `json:"get"`
+	Post   *Operation `json:"post"`
+	Put    *Operation `json:"put"`
+	Patch  *Operation `json:"patch"`
+	Delete *Operation `json:"delete"`
+}
+
+// operation возвращает операцию для HTTP-метода
+func (p *PathItem) operation(method string) *Operation {
+	switch method {
+	case http.MethodGet:
+		return p.Get
+	case http.MethodPost:
+		return p.Post
+	case http.MethodPut:
+		return p.Put
+	case http.MethodPatch:
+		return p.Patch
+	case http.MethodDelete:
+		return p.Delete
+	}
+	return nil
+}
+
+// Operation описание операции
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []Parameter  `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+// Parameter параметр запроса
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody тело запроса по типам содержимого
+type RequestBody struct {
+	Required bool `json:"required"`
+	Content  map[string]struct {
+		Schema *Schema `json:"schema"`
+	} `json:"content"`
+}
+
+// jsonSchema возвращает схему тела в формате JSON, если она описана
+func (b *RequestBody) jsonSchema() *Schema {
+	if b == nil {
+		return nil
+	}
+	if media, ok := b.Content["application/json"]; ok {
+		return media.Schema
+	}
+	return nil
+}
+
+// Schema подмножество JSON Schema, которое поддерживает проверка запросов
+type Schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Pattern    string             `json:"pattern"`
+	Enum       []interface{}      `json:"enum"`
+	Minimum    *float64           `json:"minimum"`
+	Maximum    *float64           `json:"maximum"`
+	MinLength  *int               `json:"minLength"`
+	MaxLength  *int               `json:"maxLength"`
+	Required   []string           `json:"required"`
+	Properties map[string]*Schema `json:"properties"`
+	Items      *Schema            `json:"items"`
+
+	pattern *regexp.Regexp
+}
+
+// Load разбирает встроенный документ OpenAPI и компилирует шаблоны схем
+func Load() (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("ошибка разбора openapi.json: %w", err)
+	}
+	for _, s := range doc.Components.Schemas {
+		if err := doc.compile(s); err != nil {
+			return nil, err
+		}
+	}
+	for path, item := range doc.Paths {
+		for _, op := range []*Operation{item.Get, item.Post, item.Put, item.Patch, item.Delete} {
+			if op == nil {
+				continue
+			}
+			for _, p := range op.Parameters {
+				if err := doc.compile(p.Schema); err != nil {
+					return nil, fmt.Errorf("%s: %w", path, err)
+				}
+			}
+			if err := doc.compile(op.RequestBody.jsonSchema()); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+	return &doc, nil
+}
+
+// compile проверяет ссылки и компилирует регулярные выражения схемы
+func (d *Document) compile(s *Schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		if d.resolve(s) == nil {
+			return fmt.Errorf("неизвестная ссылка %s", s.Ref)
+		}
+		return nil
+	}
+	if s.Pattern != "" && s.pattern == nil {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("неверный шаблон %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, p := range s.Properties {
+		if err := d.compile(p); err != nil {
+			return err
+		}
+	}
+	return d.compile(s.Items)
+}
+
+// resolve заменяет ссылку $ref на схему из components
+func (d *Document) resolve(s *Schema) *Schema {
+	if s == nil || s.Ref == "" {
+		return s
+	}
+	name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+	return d.Components.Schemas[name]
+}
+
+// SpecHandler отдаёт документ OpenAPI
+func SpecHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(spec)
+}
+
+// DocsHandler отдаёт страницу документации, которая читает /api/openapi.json
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// Страница не загружает ничего с других адресов
+	w.Header().Set("Content-Security-Policy", docsPolicy)
+	w.Write(docs)
+}
@@ -0,0 +1,293 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"final-project/internal/apierror"
+)
+
+// maxBodySize ограничивает размер проверяемого тела запроса
+const maxBodySize = 1 << 20
+
+// Validator проверяет параметры и тело запроса по документу OpenAPI,
+// чтобы неверные запросы отклонялись до обработчиков
+type Validator struct {
+	doc *Document
+}
+
+// NewValidator создаёт проверку запросов по документу doc
+func NewValidator(doc *Document) *Validator {
+	return &Validator{doc: doc}
+}
+
+// Middleware отклоняет запросы, не соответствующие документу, с ошибкой 422
+// (400, если тело не является JSON, и 413, если оно больше maxBodySize). Запросы к путям и методам,
+// которых нет в документе, передаются дальше без проверки.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		item, pathParams := v.find(r.URL.Path)
+		if item == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		op := item.operation(r.Method)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		fields := v.checkParameters(op, r, pathParams)
+
+		if schema := op.RequestBody.jsonSchema(); schema != nil {
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+			if err != nil {
+				apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeBadRequest).Wrap(err))
+				return
+			}
+			if len(body) > maxBodySize {
+				apierror.Write(w, r, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodeRequestTooLarge).With("limit", maxBodySize))
+				return
+			}
+			// Обработчик читает тело заново
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			if len(bytes.TrimSpace(body)) == 0 {
+				if op.RequestBody.Required {
+					fields = append(fields, apierror.Field("body", apierror.FieldRequired))
+				}
+			} else {
+				var value interface{}
+				decoder := json.NewDecoder(bytes.NewReader(body))
+				decoder.UseNumber()
+				if err := decoder.Decode(&value); err != nil {
+					apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON).Wrap(err))
+					return
+				}
+				fields = append(fields, v.validate(schema, value, "")...)
+			}
+		}
+
+		if len(fields) > 0 {
+			apierror.Write(w, r, apierror.Validation(fields...))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// find находит путь документа для пути запроса. Сегменты вида {id}
+// совпадают с любым значением и возвращаются как параметры пути.
+func (v *Validator) find(path string) (*PathItem, map[string]string) {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	if item, ok := v.doc.Paths[path]; ok {
+		return item, nil
+	}
+
+	segments := strings.Split(path, "/")
+	for template, item := range v.doc.Paths {
+		if !strings.Contains(template, "{") {
+			continue
+		}
+		parts := strings.Split(template, "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+		params := make(map[string]string)
+		matched := true
+		for i, part := range parts {
+			if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+				params[part[1:len(part)-1]] = segments[i]
+				continue
+			}
+			if part != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return item, params
+		}
+	}
+	return nil, nil
+}
+
+// checkParameters проверяет параметры пути, строки запроса и заголовки.
+// Пустое значение параметра считается отсутствующим.
+func (v *Validator) checkParameters(op *Operation, r *http.Request, pathParams map[string]string) []apierror.FieldError {
+	var fields []apierror.FieldError
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		var value string
+		switch p.In {
+		case "query":
+			value = query.Get(p.Name)
+		case "path":
+			value = pathParams[p.Name]
+		case "header":
+			value = r.Header.Get(p.Name)
+		default:
+			continue
+		}
+		if value == "" {
+			if p.Required {
+				fields = append(fields, apierror.Field(p.Name, apierror.FieldRequired))
+			}
+			continue
+		}
+		if code, ok := v.checkParameter(v.doc.resolve(p.Schema), value); !ok {
+			fields = append(fields, apierror.Field(p.Name, code))
+		}
+	}
+	return fields
+}
+
+// checkParameter проверяет строковое значение параметра по схеме
+func (v *Validator) checkParameter(s *Schema, value string) (apierror.FieldCode, bool) {
+	if s == nil {
+		return "", true
+	}
+	switch s.Type {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || (s.Type == "integer" && n != float64(int64(n))) {
+			return apierror.FieldInvalidFormat, false
+		}
+		return checkNumber(s, n)
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return apierror.FieldInvalidFormat, false
+		}
+		return "", true
+	}
+	return checkString(s, value)
+}
+
+// validate проверяет значение тела запроса по схеме; path — путь к полю
+func (v *Validator) validate(s *Schema, value interface{}, path string) []apierror.FieldError {
+	s = v.doc.resolve(s)
+	if s == nil || value == nil {
+		return nil
+	}
+	field := func(code apierror.FieldCode) []apierror.FieldError {
+		name := path
+		if name == "" {
+			name = "body"
+		}
+		return []apierror.FieldError{apierror.Field(name, code)}
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return field(apierror.FieldInvalidFormat)
+		}
+		var fields []apierror.FieldError
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				fields = append(fields, apierror.Field(joinPath(path, name), apierror.FieldRequired))
+			}
+		}
+		// Поля проверяются в алфавитном порядке, чтобы ответ не менялся от запроса к запросу
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if propValue, ok := obj[name]; ok {
+				fields = append(fields, v.validate(s.Properties[name], propValue, joinPath(path, name))...)
+			}
+		}
+		return fields
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return field(apierror.FieldInvalidFormat)
+		}
+		var fields []apierror.FieldError
+		for i, item := range items {
+			fields = append(fields, v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return fields
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return field(apierror.FieldInvalidFormat)
+		}
+		n, err := number.Float64()
+		if err != nil || (s.Type == "integer" && n != float64(int64(n))) {
+			return field(apierror.FieldInvalidFormat)
+		}
+		if code, ok := checkNumber(s, n); !ok {
+			return field(code)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return field(apierror.FieldInvalidFormat)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return field(apierror.FieldInvalidFormat)
+		}
+		if code, ok := checkString(s, str); !ok {
+			return field(code)
+		}
+	}
+	return nil
+}
+
+// checkNumber проверяет границы числа
+func checkNumber(s *Schema, n float64) (apierror.FieldCode, bool) {
+	if (s.Minimum != nil && n < *s.Minimum) || (s.Maximum != nil && n > *s.Maximum) {
+		return apierror.FieldOutOfRange, false
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, strconv.FormatFloat(n, 'f', -1, 64)) {
+		return apierror.FieldInvalidValue, false
+	}
+	return "", true
+}
+
+// checkString проверяет шаблон, длину и допустимые значения строки
+func checkString(s *Schema, value string) (apierror.FieldCode, bool) {
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		return apierror.FieldInvalidFormat, false
+	}
+	length := utf8.RuneCountInString(value)
+	if (s.MinLength != nil && length < *s.MinLength) || (s.MaxLength != nil && length > *s.MaxLength) {
+		return apierror.FieldOutOfRange, false
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		return apierror.FieldInvalidValue, false
+	}
+	return "", true
+}
+
+// inEnum проверяет, что значение входит в список допустимых
+func inEnum(enum []interface{}, value string) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// joinPath добавляет имя поля к пути: task + date = task.date
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
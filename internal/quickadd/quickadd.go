@@ -0,0 +1,420 @@
+package quickadd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"final-project/internal/moduls"
+	"final-project/internal/nextdate"
+	"final-project/internal/utils"
+)
+
+// Регулярные выражения для частей фраз
+const (
+	weekdayPattern  = `(?:monday|mon|tuesday|tues|tue|wednesday|wed|thursday|thurs|thu|friday|fri|saturday|sat|sunday|sun)s?`
+	weekdayList     = weekdayPattern + `(?:\s*(?:,|and|&)\s*` + weekdayPattern + `)*`
+	monthPattern    = `(?:january|jan|february|feb|march|mar|april|apr|may|june|jun|july|jul|august|aug|september|sept|sep|october|oct|november|nov|december|dec)`
+	dayOfMonth      = `(?:last\s+day|\d{1,2}(?:st|nd|rd|th)?)`
+	dayOfMonthList  = dayOfMonth + `(?:\s*(?:,|and|&)\s*(?:the\s+)?` + dayOfMonth + `)*`
+	intervalPattern = `(other\s+|\d+\s+)?`
+	nthPattern      = `(first|second|third|fourth|fifth|last|\d(?:st|nd|rd|th))`
+)
+
+var (
+	reWeekdays     = regexp.MustCompile(`(?i)\bevery\s+(?:weekday|workday|business\s+day)\b|\bon\s+weekdays\b`)
+	reWeekend      = regexp.MustCompile(`(?i)\bevery\s+weekend\b|\bon\s+weekends\b`)
+	reBusinessDays = regexp.MustCompile(`(?i)\bevery\s+(\d+)\s+(?:business|working)\s+days\b`)
+	reDays         = regexp.MustCompile(`(?i)\bevery\s+` + intervalPattern + `days?\b|\bdaily\b`)
+	reNthWeekday   = regexp.MustCompile(`(?i)\bevery\s+` + nthPattern + `\s+(` + weekdayPattern + `)(?:\s+of\s+(?:the|each|every)\s+month)?\b`)
+	reWeekdayList  = regexp.MustCompile(`(?i)\bevery\s+` + intervalPattern + `(` + weekdayList + `)\b`)
+	reWeeks        = regexp.MustCompile(`(?i)(?:\bevery\s+` + intervalPattern + `weeks?\b|\bweekly\b)(?:\s+on\s+(` + weekdayList + `)\b)?`)
+	reMonths       = regexp.MustCompile(`(?i)\b(?:on\s+(?:the\s+)?|the\s+)(` + dayOfMonthList + `)\s+(?:of\s+)?(?:every|each)\s+month\b|(?:\b(?:every|each)\s+month\b|\bmonthly\b)(?:\s+on\s+(?:the\s+)?(` + dayOfMonthList + `)\b)?`)
+	reYears        = regexp.MustCompile(`(?i)(?:\bevery\s+year\b|\byearly\b|\bannually\b)(?:\s+on\s+(` + monthPattern + `\s+\d{1,2}|\d{1,2}\s+` + monthPattern + `)\b)?`)
+
+	reToday     = regexp.MustCompile(`(?i)\btoday\b`)
+	reTomorrow  = regexp.MustCompile(`(?i)\btomorrow\b`)
+	reInDays    = regexp.MustCompile(`(?i)\bin\s+(\d+)\s+(days?|weeks?)\b`)
+	reNext      = regexp.MustCompile(`(?i)\bnext\s+(` + weekdayPattern + `)\b`)
+	reISODate   = regexp.MustCompile(`(?i)\bon\s+(\d{4}-\d{2}-\d{2}|\d{2}\.\d{2}\.\d{4}|\d{8})\b`)
+	reMonthDate = regexp.MustCompile(`(?i)\bon\s+(` + monthPattern + `\s+\d{1,2}|\d{1,2}\s+` + monthPattern + `)(?:st|nd|rd|th)?\b`)
+
+	reSeparators = regexp.MustCompile(`\s*(?:,|and|&)\s*(?:the\s+)?`)
+	reSpaces     = regexp.MustCompile(`\s+`)
+)
+
+// ErrEmptyTitle возвращается, если после разбора фразы не осталось названия задачи
+var ErrEmptyTitle = errors.New("не указано название задачи")
+
+// UnsupportedError возвращается, если во фразе есть теги (#finance) или
+// приоритет (!high): у задач их нет, а молча отбрасывать их нельзя
+type UnsupportedError struct {
+	Tags     []string
+	Priority []string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("теги %v и приоритет %v не поддерживаются", e.Tags, e.Priority)
+}
+
+// reMarker слово-тег (#finance) или приоритет (!high); "#123" тегом не считается
+var reMarker = regexp.MustCompile(`^([#!])\pL`)
+
+// Parse разбирает фразу вроде "Pay rent every month on the 1st" в задачу
+// с правилом повтора в формате nextdate. today — текущая дата (полночь UTC),
+// от которой считаются относительные даты. Фраза с тегами или приоритетом
+// отклоняется с *UnsupportedError.
+func Parse(text string, today time.Time) (moduls.QuickTask, error) {
+	var result moduls.QuickTask
+
+	unsupported := &UnsupportedError{}
+	for _, word := range strings.Fields(text) {
+		if m := reMarker.FindStringSubmatch(word); m != nil {
+			if m[1] == "#" {
+				unsupported.Tags = append(unsupported.Tags, word)
+			} else {
+				unsupported.Priority = append(unsupported.Priority, word)
+			}
+		}
+	}
+	if len(unsupported.Tags) > 0 || len(unsupported.Priority) > 0 {
+		return result, unsupported
+	}
+	rest := text
+
+	// Правило повтора выделяется первым, чтобы "on the 1st" не принять за дату
+	rule, rest, err := extractRule(rest)
+	if err != nil {
+		return result, err
+	}
+	date, hasDate, rest, err := extractDate(rest, today)
+	if err != nil {
+		return result, err
+	}
+	if !hasDate {
+		date = today
+	}
+
+	repeat := ""
+	if rule != nil {
+		repeat, err = rule(date)
+		if err != nil {
+			return result, err
+		}
+		// Без явной даты задача начинается с первого повторения, начиная с сегодняшнего дня
+		if !hasDate {
+			date, err = firstOccurrence(today, repeat)
+			if err != nil {
+				return result, err
+			}
+		}
+		if _, err := nextdate.NextDate(today, date.Format(utils.DateFormat), repeat); err != nil {
+			return result, fmt.Errorf("неверное правило повтора %q: %w", repeat, err)
+		}
+	}
+
+	title := strings.Trim(reSpaces.ReplaceAllString(rest, " "), " ,.;:-")
+	if title == "" {
+		return result, ErrEmptyTitle
+	}
+
+	result.Task = moduls.Scheduler{
+		Title:  title,
+		Date:   date.Format(utils.DateFormat),
+		Repeat: repeat,
+	}
+	return result, nil
+}
+
+// ruleFunc строит правило повтора; дата нужна правилам, которые по умолчанию
+// повторяются в тот же день недели или месяца
+type ruleFunc func(date time.Time) (string, error)
+
+// extractRule находит во фразе правило повтора и удаляет его из текста
+func extractRule(text string) (ruleFunc, string, error) {
+	if loc := reWeekdays.FindStringIndex(text); loc != nil {
+		return fixedRule("w 1,2,3,4,5"), cut(text, loc), nil
+	}
+	if loc := reWeekend.FindStringIndex(text); loc != nil {
+		return fixedRule("w 6,7"), cut(text, loc), nil
+	}
+	if m := reBusinessDays.FindStringSubmatchIndex(text); m != nil {
+		return fixedRule("b " + text[m[2]:m[3]]), cut(text, m[:2]), nil
+	}
+	if m := reNthWeekday.FindStringSubmatchIndex(text); m != nil {
+		n, err := parseNth(text[m[2]:m[3]])
+		if err != nil {
+			return nil, text, err
+		}
+		weekday := weekdayNumber(text[m[4]:m[5]])
+		return fixedRule(fmt.Sprintf("mw %d-%d", n, weekday)), cut(text, m[:2]), nil
+	}
+	if m := reDays.FindStringSubmatchIndex(text); m != nil {
+		interval, err := parseInterval(text, m[2], m[3])
+		if err != nil {
+			return nil, text, err
+		}
+		return fixedRule(fmt.Sprintf("d %d", interval)), cut(text, m[:2]), nil
+	}
+	if m := reWeekdayList.FindStringSubmatchIndex(text); m != nil {
+		interval, err := parseInterval(text, m[2], m[3])
+		if err != nil {
+			return nil, text, err
+		}
+		days := weekdayNumbers(text[m[4]:m[5]])
+		return fixedRule(weeklyRule(days, interval)), cut(text, m[:2]), nil
+	}
+	if m := reWeeks.FindStringSubmatchIndex(text); m != nil {
+		interval, err := parseInterval(text, m[2], m[3])
+		if err != nil {
+			return nil, text, err
+		}
+		var days string
+		if m[4] >= 0 {
+			days = weekdayNumbers(text[m[4]:m[5]])
+		}
+		return func(date time.Time) (string, error) {
+			if days == "" {
+				days = strconv.Itoa(weekdayOf(date))
+			}
+			return weeklyRule(days, interval), nil
+		}, cut(text, m[:2]), nil
+	}
+	if m := reMonths.FindStringSubmatchIndex(text); m != nil {
+		// День месяца стоит перед "every month" или после "monthly on"
+		var days string
+		for _, group := range [][]int{m[2:4], m[4:6]} {
+			if group[0] < 0 {
+				continue
+			}
+			var err error
+			if days, err = monthDays(text[group[0]:group[1]]); err != nil {
+				return nil, text, err
+			}
+		}
+		return func(date time.Time) (string, error) {
+			if days == "" {
+				days = strconv.Itoa(date.Day())
+			}
+			return "m " + days, nil
+		}, cut(text, m[:2]), nil
+	}
+	if m := reYears.FindStringSubmatchIndex(text); m != nil {
+		repeat := "y"
+		if m[2] >= 0 {
+			month, day, err := parseMonthDay(text[m[2]:m[3]])
+			if err != nil {
+				return nil, text, err
+			}
+			repeat = fmt.Sprintf("y %02d%02d", month, day)
+		}
+		return fixedRule(repeat), cut(text, m[:2]), nil
+	}
+	return nil, text, nil
+}
+
+// extractDate находит во фразе дату задачи и удаляет её из текста
+func extractDate(text string, today time.Time) (time.Time, bool, string, error) {
+	if loc := reToday.FindStringIndex(text); loc != nil {
+		return today, true, cut(text, loc), nil
+	}
+	if loc := reTomorrow.FindStringIndex(text); loc != nil {
+		return today.AddDate(0, 0, 1), true, cut(text, loc), nil
+	}
+	if m := reInDays.FindStringSubmatchIndex(text); m != nil {
+		n, err := strconv.Atoi(text[m[2]:m[3]])
+		if err != nil || n > 3650 {
+			return today, false, text, fmt.Errorf("неверный срок: %s", text[m[0]:m[1]])
+		}
+		if strings.HasPrefix(strings.ToLower(text[m[4]:m[5]]), "week") {
+			n *= 7
+		}
+		return today.AddDate(0, 0, n), true, cut(text, m[:2]), nil
+	}
+	if m := reNext.FindStringSubmatchIndex(text); m != nil {
+		weekday := weekdayNumber(text[m[2]:m[3]])
+		days := (weekday-weekdayOf(today)+6)%7 + 1
+		return today.AddDate(0, 0, days), true, cut(text, m[:2]), nil
+	}
+	if m := reISODate.FindStringSubmatchIndex(text); m != nil {
+		value := text[m[2]:m[3]]
+		for _, layout := range []string{"2006-01-02", utils.DateFormatDB, utils.DateFormat} {
+			if date, err := time.Parse(layout, value); err == nil {
+				return date, true, cut(text, m[:2]), nil
+			}
+		}
+		return today, false, text, fmt.Errorf("неверная дата: %s", value)
+	}
+	if m := reMonthDate.FindStringSubmatchIndex(text); m != nil {
+		month, day, err := parseMonthDay(text[m[2]:m[3]])
+		if err != nil {
+			return today, false, text, err
+		}
+		// Ближайшая такая дата: в этом году или, если она прошла, в следующем
+		date := time.Date(today.Year(), time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		if date.Before(today) {
+			date = date.AddDate(1, 0, 0)
+		}
+		return date, true, cut(text, m[:2]), nil
+	}
+	return today, false, text, nil
+}
+
+// firstOccurrence возвращает первую дату повтора, начиная с today включительно
+func firstOccurrence(today time.Time, repeat string) (time.Time, error) {
+	// Интервальные правила начинаются с сегодняшнего дня
+	if repeat == "y" || strings.HasPrefix(repeat, "d ") || strings.HasPrefix(repeat, "b ") {
+		return today, nil
+	}
+	next, err := nextdate.NextDate(today.AddDate(0, 0, -1), today.Format(utils.DateFormat), repeat)
+	if err != nil {
+		return today, fmt.Errorf("неверное правило повтора %q: %w", repeat, err)
+	}
+	return time.Parse(utils.DateFormat, next)
+}
+
+// fixedRule возвращает правило, не зависящее от даты задачи
+func fixedRule(repeat string) ruleFunc {
+	return func(time.Time) (string, error) { return repeat, nil }
+}
+
+// weeklyRule строит правило "w" с необязательным интервалом недель
+func weeklyRule(days string, interval int) string {
+	if interval > 1 {
+		return fmt.Sprintf("w %s /%d", days, interval)
+	}
+	return "w " + days
+}
+
+// parseInterval разбирает "other " или "N " перед единицей времени
+func parseInterval(text string, start, end int) (int, error) {
+	if start < 0 {
+		return 1, nil
+	}
+	value := strings.ToLower(strings.TrimSpace(text[start:end]))
+	if value == "other" {
+		return 2, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("неверный интервал: %s", value)
+	}
+	return n, nil
+}
+
+// parseNth разбирает порядковый номер дня недели в месяце
+func parseNth(value string) (int, error) {
+	switch strings.ToLower(value) {
+	case "first":
+		return 1, nil
+	case "second":
+		return 2, nil
+	case "third":
+		return 3, nil
+	case "fourth":
+		return 4, nil
+	case "fifth":
+		return 5, nil
+	case "last":
+		return -1, nil
+	}
+	n, err := strconv.Atoi(strings.TrimRight(strings.ToLower(value), "stndrh"))
+	if err != nil || n < 1 || n > 5 {
+		return 0, fmt.Errorf("неверный номер дня недели: %s", value)
+	}
+	return n, nil
+}
+
+// monthDays разбирает список дней месяца вида "1st and 15th" или "last day"
+func monthDays(list string) (string, error) {
+	var days []string
+	for _, item := range reSeparators.Split(strings.ToLower(list), -1) {
+		item = strings.TrimSpace(item)
+		if strings.HasPrefix(item, "last") {
+			days = append(days, "-1")
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimRight(item, "stndrh"))
+		if err != nil || n < 1 || n > 31 {
+			return "", fmt.Errorf("неверный день месяца: %s", item)
+		}
+		days = append(days, strconv.Itoa(n))
+	}
+	return strings.Join(days, ","), nil
+}
+
+// parseMonthDay разбирает дату вида "march 15" или "15 march"
+func parseMonthDay(value string) (int, int, error) {
+	fields := strings.Fields(strings.ToLower(value))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("неверная дата: %s", value)
+	}
+	monthName, dayStr := fields[0], fields[1]
+	if _, err := strconv.Atoi(monthName); err == nil {
+		monthName, dayStr = dayStr, monthName
+	}
+	month := monthNumber(monthName)
+	day, err := strconv.Atoi(dayStr)
+	if month == 0 || err != nil {
+		return 0, 0, fmt.Errorf("неверная дата: %s", value)
+	}
+	// 2000 год високосный, поэтому 29 февраля допускается
+	if _, err := time.Parse("20060102", fmt.Sprintf("2000%02d%02d", month, day)); err != nil {
+		return 0, 0, fmt.Errorf("неверная дата: %s", value)
+	}
+	return month, day, nil
+}
+
+// monthNumber возвращает номер месяца по полному или сокращённому названию
+func monthNumber(name string) int {
+	for m := time.January; m <= time.December; m++ {
+		full := strings.ToLower(m.String())
+		if name == full || len(name) >= 3 && strings.HasPrefix(full, name) {
+			return int(m)
+		}
+	}
+	return 0
+}
+
+// weekdayNumbers переводит список названий дней недели в список номеров для правила "w"
+func weekdayNumbers(list string) string {
+	var days []string
+	seen := make(map[int]bool)
+	for _, name := range reSeparators.Split(list, -1) {
+		n := weekdayNumber(name)
+		if n == 0 || seen[n] {
+			continue
+		}
+		seen[n] = true
+		days = append(days, strconv.Itoa(n))
+	}
+	return strings.Join(days, ",")
+}
+
+// weekdayNumber возвращает номер дня недели от 1 (понедельник) до 7 по названию
+func weekdayNumber(name string) int {
+	name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), "s")
+	for d := 1; d <= 7; d++ {
+		full := strings.ToLower(time.Weekday(d % 7).String())
+		if name == full || len(name) >= 3 && strings.HasPrefix(full, name) {
+			return d
+		}
+	}
+	return 0
+}
+
+// weekdayOf возвращает номер дня недели даты от 1 (понедельник) до 7
+func weekdayOf(date time.Time) int {
+	if date.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(date.Weekday())
+}
+
+// cut удаляет из текста фрагмент с границами loc
+func cut(text string, loc []int) string {
+	return text[:loc[0]] + " " + text[loc[1]:]
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"final-project/internal/apierror"
+	"final-project/internal/moduls"
+)
+
+// Значения по умолчанию для незаданных настроек
+const (
+	DefaultReadRate   = 50
+	DefaultReadBurst  = 200
+	DefaultWriteRate  = 20
+	DefaultWriteBurst = 100
+)
+
+// addressFactor во сколько раз лимит адреса больше лимита клиента:
+// за одним адресом может быть несколько клиентов, например за NAT
+const addressFactor = 5
+
+// Middleware ограничивает частоту запросов клиента. Чтение (GET, HEAD, OPTIONS)
+// и изменения учитываются в разных корзинах, чтобы поток поисковых запросов
+// не мешал сохранять задачи. Клиент определяется по адресу (RealIP) и токену
+// авторизации. Токен пока не проверяется, поэтому все запросы с адреса
+// учитываются ещё и в общей корзине адреса: смена токена на каждом запросе
+// не даёт новой полной корзины.
+func Middleware(cfg moduls.RateLimit) func(http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	readRate, readBurst := orDefault(cfg.ReadRate, DefaultReadRate), orDefaultInt(cfg.ReadBurst, DefaultReadBurst)
+	writeRate, writeBurst := orDefault(cfg.WriteRate, DefaultWriteRate), orDefaultInt(cfg.WriteBurst, DefaultWriteBurst)
+	read, readAddress := NewLimiter(readRate, readBurst), NewLimiter(readRate*addressFactor, readBurst*addressFactor)
+	write, writeAddress := NewLimiter(writeRate, writeBurst), NewLimiter(writeRate*addressFactor, writeBurst*addressFactor)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limiter, address, scope := write, writeAddress, "write"
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				limiter, address, scope = read, readAddress, "read"
+			}
+
+			// Сначала общая корзина адреса, затем корзина клиента
+			host := clientAddress(r)
+			result := address.Allow("ip:" + host)
+			if result.Allowed {
+				result = limiter.Allow(clientKey(r, host))
+			} else {
+				limiter = address
+			}
+			setHeaders(w, limiter, scope, result)
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+				apierror.Write(w, r, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setHeaders добавляет заголовки RateLimit-* (draft-ietf-httpapi-ratelimit-headers)
+func setHeaders(w http.ResponseWriter, l *Limiter, scope string, result Result) {
+	window := seconds(l.duration(l.burst))
+	w.Header().Set("RateLimit-Policy", strconv.Itoa(result.Limit)+";w="+strconv.Itoa(window)+`;comment="`+scope+`"`)
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+}
+
+// clientAddress адрес клиента без порта
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+// clientKey ключ клиента: адрес host и хэш токена, если он есть
+func clientKey(r *http.Request, host string) string {
+	if token := r.Header.Get("Authorization"); token != "" {
+		sum := sha256.Sum256([]byte(token))
+		return "ip:" + host + ",token:" + hex.EncodeToString(sum[:8])
+	}
+	return "ip:" + host
+}
+
+// seconds округляет время вверх до целых секунд
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func orDefault(value, def float64) float64 {
+	if value <= 0 {
+		return def
+	}
+	return value
+}
+
+func orDefaultInt(value, def int) int {
+	if value <= 0 {
+		return def
+	}
+	return value
+}
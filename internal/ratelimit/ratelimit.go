@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval как часто удаляются корзины неактивных клиентов
+const sweepInterval = time.Minute
+
+// maxBuckets сколько клиентов отслеживается одновременно. Когда корзин
+// больше, новые клиенты до очистки делят одну общую корзину overflowKey.
+const (
+	maxBuckets  = 100000
+	overflowKey = "overflow"
+)
+
+// Result итог проверки запроса
+type Result struct {
+	Allowed bool
+	// Limit ёмкость корзины — сколько запросов можно сделать подряд
+	Limit int
+	// Remaining сколько запросов осталось до ограничения
+	Remaining int
+	// Reset через сколько корзина заполнится полностью
+	Reset time.Duration
+	// RetryAfter через сколько появится следующий токен; 0, если запрос разрешён
+	RetryAfter time.Duration
+}
+
+// bucket корзина токенов одного клиента
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter ограничивает частоту запросов алгоритмом token bucket: у каждого
+// клиента своя корзина на burst токенов, которая пополняется со скоростью rate
+// токенов в секунду, а каждый запрос забирает один токен
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewLimiter создаёт ограничитель: rate — запросов в секунду, burst — ёмкость корзины
+func NewLimiter(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:      rate,
+		burst:     float64(burst),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow забирает токен из корзины клиента key, если он есть
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok && len(l.buckets) >= maxBuckets {
+		key = overflowKey
+		b, ok = l.buckets[key]
+	}
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	result := Result{Limit: int(l.burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.duration(1 - b.tokens)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = l.duration(l.burst - b.tokens)
+	return result
+}
+
+// duration время, за которое корзина пополнится на tokens токенов
+func (l *Limiter) duration(tokens float64) time.Duration {
+	if l.rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// sweep удаляет корзины, которые успели заполниться: такой клиент неотличим от нового
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -1,11 +1,23 @@
 package router
 
 import (
+	"context"
+	"final-project/internal/apierror"
+	"final-project/internal/audit"
 	"final-project/internal/auth"
+	"final-project/internal/clock"
 	"final-project/internal/database"
+	"final-project/internal/health"
+	"final-project/internal/idempotency"
+	"final-project/internal/metrics"
+	"final-project/internal/moduls"
+	"final-project/internal/openapi"
+	"final-project/internal/ratelimit"
 	"final-project/internal/tasks"
-	"log"
+	"final-project/internal/timezone"
+	"log/slog"
 	"net/http"
+	"os"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/v5/middleware"
@@ -17,30 +29,127 @@ const (
 	tasksPath = "/tasks"
 )
 
-// SetupRouter настраивает маршруты для API
-func SetupRouter(r *chi.Mux, db *database.DB) {
+// SetupRouter настраивает маршруты для API. Фоновые задачи маршрутов
+// (очистка сохранённых ответов) работают до отмены ctx. Часы экземпляра —
+// часы базы данных db.
+func SetupRouter(ctx context.Context, r *chi.Mux, db *database.DB, cfg *moduls.Config) {
 	// Добавляем глобальные middleware
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
-	r.Use(auth.LoggingMiddleware)
+	r.Use(metrics.Middleware)
+	r.Use(auth.LoggingMiddleware(cfg.Log.Body))
 	r.Use(auth.AuthMiddleware)
+	r.Use(audit.Middleware)
 	r.Use(middleware.Recoverer)
 
+	// Описание API, по которому проверяются запросы
+	doc, err := openapi.Load()
+	if err != nil {
+		slog.Error("Ошибка загрузки описания API", "error", err)
+		os.Exit(1)
+	}
+	validator := openapi.NewValidator(doc)
+
+	// Сохранённые ответы для повторов создания и выполнения задач
+	idempotent := idempotency.NewStore(ctx, cfg.IdempotencyTTL).Middleware
+
+	// Проверки состояния для оркестратора и дежурных
+	checker := health.NewChecker(db, cfg.DBFile)
+
+	// Метрики Prometheus; число задач считается при каждом запросе метрик
+	metrics.NewGaugeFunc("scheduler_tasks", "Number of tasks by repeat rule type.", "repeat", func() (map[string]float64, error) {
+		counts, err := db.CountByRepeat(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]float64, len(counts))
+		for kind, n := range counts {
+			values[kind] = float64(n)
+		}
+		return values, nil
+	})
+	// Метрики содержат число задач и сведения о запросах, поэтому на основном
+	// порту требуют авторизации. С TODO_METRICS_ADDR они отдаются только на
+	// отдельном адресе, который не должен быть доступен снаружи.
+	if cfg.MetricsAddr == "" {
+		r.Get("/metrics", metrics.Handler)
+	}
+
+	// Проверки оркестратора регистрируются вне группы /api: они не должны
+	// получать 429 из-за общего лимита адреса, с которого приходят
+	r.Get(apiPrefix+"/health/live", checker.Live)
+	r.Get(apiPrefix+"/health/ready", checker.Ready)
+
 	// API маршруты
 	r.Route(apiPrefix, func(r chi.Router) {
+		r.Use(ratelimit.Middleware(cfg.RateLimit))
+		// Часовой пояс и часы нужны только API: статические файлы не зависят
+		// от них и не должны обращаться к настройкам пользователя
+		r.Use(timezone.Middleware(cfg.Location, func(r *http.Request) (string, error) {
+			// Настройки хранятся только для запросов с токеном
+			actor := audit.Actor(r)
+			if actor == audit.Anonymous {
+				return "", nil
+			}
+			return db.UserTimezone(r.Context(), actor)
+		}))
+		r.Use(clock.Middleware(db, cfg.DebugClock))
+		r.Use(validator.Middleware)
+
+		// Неизвестные маршруты API отвечают ошибкой в формате API, а не страницей
+		r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+			apierror.Write(w, r, apierror.New(http.StatusNotFound, apierror.CodeNotFound))
+		})
+		r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+			apierror.Write(w, r, apierror.New(http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed))
+		})
+
 		// Маршруты для задач
 		r.Route(taskPath, func(r chi.Router) {
 			r.Get("/", func(w http.ResponseWriter, r *http.Request) { tasks.TaskHandler(w, r, db) })
-			r.Post("/", func(w http.ResponseWriter, r *http.Request) { tasks.TaskHandler(w, r, db) })
+			r.With(idempotent).Post("/", func(w http.ResponseWriter, r *http.Request) { tasks.TaskHandler(w, r, db) })
 			r.Put("/", func(w http.ResponseWriter, r *http.Request) { tasks.TaskHandler(w, r, db) })
+			r.Patch("/", func(w http.ResponseWriter, r *http.Request) { tasks.TaskHandler(w, r, db) })
 			r.Delete("/", func(w http.ResponseWriter, r *http.Request) { tasks.TaskHandler(w, r, db) })
-			r.Post("/done", func(w http.ResponseWriter, r *http.Request) { tasks.HandleTaskDone(w, r, db) })
+			r.With(idempotent).Post("/done", func(w http.ResponseWriter, r *http.Request) { tasks.HandleTaskDone(w, r, db) })
+			r.Post("/quick", func(w http.ResponseWriter, r *http.Request) { tasks.QuickTaskHandler(w, r, db) })
+			r.Post("/skip", func(w http.ResponseWriter, r *http.Request) { tasks.SkipTaskHandler(w, r, db) })
+			r.Post("/snooze", func(w http.ResponseWriter, r *http.Request) { tasks.SnoozeTaskHandler(w, r, db) })
+			r.Post("/dependency", func(w http.ResponseWriter, r *http.Request) { tasks.DependencyHandler(w, r, db) })
+			r.Delete("/dependency", func(w http.ResponseWriter, r *http.Request) { tasks.DependencyHandler(w, r, db) })
+			r.Get("/exception", func(w http.ResponseWriter, r *http.Request) { tasks.ExceptionHandler(w, r, db) })
+			r.Post("/exception", func(w http.ResponseWriter, r *http.Request) { tasks.ExceptionHandler(w, r, db) })
+			r.Delete("/exception", func(w http.ResponseWriter, r *http.Request) { tasks.ExceptionHandler(w, r, db) })
+			r.Post("/calendar", func(w http.ResponseWriter, r *http.Request) { tasks.TaskCalendarHandler(w, r, db) })
+			r.Delete("/calendar", func(w http.ResponseWriter, r *http.Request) { tasks.TaskCalendarHandler(w, r, db) })
+		})
+
+		// API v2: задачи как ресурсы с идентификатором в пути
+		r.Route("/v2/tasks", func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) { tasks.ListTasksV2(w, r, db) })
+			r.With(idempotent).Post("/", func(w http.ResponseWriter, r *http.Request) { tasks.CreateTaskV2(w, r, db) })
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", func(w http.ResponseWriter, r *http.Request) { tasks.GetTaskV2(w, r, db) })
+				r.Put("/", func(w http.ResponseWriter, r *http.Request) { tasks.ReplaceTaskV2(w, r, db) })
+				r.Patch("/", func(w http.ResponseWriter, r *http.Request) { tasks.PatchTaskV2(w, r, db) })
+				r.Delete("/", func(w http.ResponseWriter, r *http.Request) { tasks.DeleteTaskV2(w, r, db) })
+				r.With(idempotent).Post("/complete", func(w http.ResponseWriter, r *http.Request) { tasks.CompleteTaskV2(w, r, db) })
+			})
 		})
 
 		// Дополнительные маршруты
 		r.Get("/nextdate", tasks.NextDateHandler)
+		r.Get("/nextdate/preview", tasks.NextDatePreviewHandler)
 		r.Get("/tasks", func(w http.ResponseWriter, r *http.Request) { tasks.GetTasksHandler(w, r, db) })
+		r.Get("/calendars", func(w http.ResponseWriter, r *http.Request) { tasks.GetCalendarsHandler(w, r, db) })
+		r.Post("/calendar/import", func(w http.ResponseWriter, r *http.Request) { tasks.ImportCalendarHandler(w, r, db) })
+		r.Get("/audit", func(w http.ResponseWriter, r *http.Request) { tasks.AuditHandler(w, r, db) })
+		r.Get("/settings", func(w http.ResponseWriter, r *http.Request) { tasks.SettingsHandler(w, r, db) })
+		r.Put("/settings", func(w http.ResponseWriter, r *http.Request) { tasks.SettingsHandler(w, r, db) })
 		r.Get("/health", HealthCheckHandler(db))
+		r.Get("/health/details", checker.Details)
+		r.Get("/openapi.json", openapi.SpecHandler)
+		r.Get("/docs", openapi.DocsHandler)
 	})
 }
 
@@ -48,8 +157,7 @@ func SetupRouter(r *chi.Mux, db *database.DB) {
 func HealthCheckHandler(db *database.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := db.Ping(); err != nil {
-			log.Printf("Ошибка проверки соединения с базой данных: %v", err)
-			http.Error(w, "Database connection error", http.StatusInternalServerError)
+			apierror.Write(w, r, apierror.New(http.StatusServiceUnavailable, apierror.CodeServiceUnavailable).Wrap(err))
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -0,0 +1,123 @@
+package tasks
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"final-project/internal/apierror"
+	"final-project/internal/clock"
+	"final-project/internal/database"
+	"final-project/internal/moduls"
+	"final-project/internal/timezone"
+	"final-project/internal/utils"
+)
+
+// Число записей журнала аудита в ответе по умолчанию и наибольшее
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditHandler обрабатывает запросы к /api/audit.
+// Параметры task_id, actor и action отбирают записи, from и to — интервал
+// времени (дата YYYYMMDD в часовом поясе запроса или время RFC 3339;
+// дата в to включается целиком). format=csv выгружает все подходящие
+// записи файлом CSV, если не задан limit.
+func AuditHandler(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.AuditHandler")
+	defer span.End()
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	filter := moduls.AuditFilter{
+		TaskID: query.Get("task_id"),
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+	}
+
+	var fields []apierror.FieldError
+	if filter.TaskID != "" {
+		if _, err := strconv.Atoi(filter.TaskID); err != nil {
+			fields = append(fields, apierror.Field("task_id", apierror.FieldInvalidFormat))
+		}
+	}
+	loc := timezone.FromContext(r.Context())
+	var err error
+	if value := query.Get("from"); value != "" {
+		if filter.From, err = clock.Parse(value, loc); err != nil {
+			fields = append(fields, apierror.Field("from", apierror.FieldInvalidFormat))
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		if filter.To, err = clock.Parse(value, loc); err != nil {
+			fields = append(fields, apierror.Field("to", apierror.FieldInvalidFormat))
+		} else if _, err := time.Parse(utils.DateFormat, value); err == nil {
+			// Дата без времени включается целиком
+			filter.To = filter.To.AddDate(0, 0, 1)
+		}
+	}
+	if format != "" && format != "json" && format != "csv" {
+		fields = append(fields, apierror.Field("format", apierror.FieldInvalidValue))
+	}
+	if format != "csv" {
+		filter.Limit = defaultAuditLimit
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		switch {
+		case err != nil:
+			fields = append(fields, apierror.Field("limit", apierror.FieldInvalidFormat))
+		case limit < 1 || limit > maxAuditLimit:
+			fields = append(fields, apierror.Field("limit", apierror.FieldOutOfRange))
+		default:
+			filter.Limit = limit
+		}
+	}
+	if len(fields) > 0 {
+		apierror.Write(w, r, apierror.Validation(fields...))
+		return
+	}
+
+	entries, err := db.AuditLog(r.Context(), filter)
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	if format == "csv" {
+		writeAuditCSV(w, entries)
+		return
+	}
+	utils.SendJSON(w, http.StatusOK, map[string]interface{}{
+		"entries": entries,
+	})
+}
+
+// writeAuditCSV выгружает записи аудита в CSV; состояния задачи
+// записываются в колонки before и after в виде JSON
+func writeAuditCSV(w http.ResponseWriter, entries []moduls.AuditEntry) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-`+time.Now().UTC().Format(utils.DateFormat)+`.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "time", "actor", "request_id", "action", "task_id", "before", "after"})
+	for _, e := range entries {
+		out.Write([]string{
+			strconv.FormatInt(e.ID, 10), e.Time, e.Actor, e.RequestID, e.Action, e.TaskID,
+			snapshotCSV(e.Before), snapshotCSV(e.After),
+		})
+	}
+	out.Flush()
+}
+
+// snapshotCSV представляет состояние задачи в ячейке CSV
+func snapshotCSV(task *moduls.Scheduler) string {
+	if task == nil {
+		return ""
+	}
+	data, _ := json.Marshal(task)
+	return string(data)
+}
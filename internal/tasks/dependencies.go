@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"context"
+	"net/http"
+
+	"final-project/internal/apierror"
+	"final-project/internal/database"
+	"final-project/internal/moduls"
+	"final-project/internal/utils"
+)
+
+// DependencyHandler обрабатывает запросы к /api/task/dependency.
+// Параметр id задаёт зависимую задачу, depends_on — блокирующую.
+func DependencyHandler(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.DependencyHandler")
+	defer span.End()
+
+	id, apiErr := queryID(r, "id")
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	blockerID, apiErr := queryID(r, "depends_on")
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		err = db.AddDependency(r.Context(), id, blockerID)
+	case http.MethodDelete:
+		err = db.RemoveDependency(r.Context(), id, blockerID)
+	default:
+		methodNotAllowed(w, r)
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+
+	utils.SendJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+// taskDetails дополняет задачу списками блокирующих и зависимых задач
+func taskDetails(ctx context.Context, db *database.DB, task moduls.Scheduler) (moduls.TaskDetails, error) {
+	blockedBy, err := db.Blockers(ctx, task.ID)
+	if err != nil {
+		return moduls.TaskDetails{}, err
+	}
+	blocks, err := db.Blocking(ctx, task.ID)
+	if err != nil {
+		return moduls.TaskDetails{}, err
+	}
+	return moduls.TaskDetails{
+		Scheduler: task,
+		BlockedBy: blockedBy,
+		Blocks:    blocks,
+	}, nil
+}
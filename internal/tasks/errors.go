@@ -0,0 +1,59 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"final-project/internal/apierror"
+	"final-project/internal/database"
+)
+
+// dbError переводит ошибки базы данных в ошибки API. Истёкший тайм-аут
+// запроса — 504, занятая база данных или отменённый запрос — 503.
+// Неизвестные ошибки считаются внутренними.
+func dbError(err error) *apierror.Error {
+	var code apierror.Code
+	status := http.StatusNotFound
+	switch {
+	case errors.Is(err, database.ErrTaskNotFound):
+		code = apierror.CodeTaskNotFound
+	case errors.Is(err, database.ErrDependencyNotFound):
+		code = apierror.CodeDependencyNotFound
+	case errors.Is(err, database.ErrExceptionNotFound):
+		code = apierror.CodeExceptionNotFound
+	case errors.Is(err, database.ErrCalendarNotFound):
+		code = apierror.CodeCalendarNotFound
+	case errors.Is(err, database.ErrSelfDependency):
+		code, status = apierror.CodeSelfDependency, http.StatusConflict
+	case errors.Is(err, database.ErrDependencyCycle):
+		code, status = apierror.CodeDependencyCycle, http.StatusConflict
+	case errors.Is(err, context.DeadlineExceeded):
+		// Запрос не уложился в тайм-аут базы данных
+		code, status = apierror.CodeTimeout, http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled), database.IsBusy(err):
+		// Клиент отменил запрос или база данных занята: запрос можно повторить
+		code, status = apierror.CodeServiceUnavailable, http.StatusServiceUnavailable
+	default:
+		return apierror.Internal(err)
+	}
+	return apierror.New(status, code).Wrap(err)
+}
+
+// methodNotAllowed отправляет ошибку о неподдерживаемом методе
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	apierror.Write(w, r, apierror.New(http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed))
+}
+
+// queryID читает из параметров запроса числовой идентификатор name
+func queryID(r *http.Request, name string) (string, *apierror.Error) {
+	id := r.URL.Query().Get(name)
+	if id == "" {
+		return "", apierror.Invalid(name, apierror.FieldRequired)
+	}
+	if _, err := strconv.Atoi(id); err != nil {
+		return "", apierror.Invalid(name, apierror.FieldInvalidFormat)
+	}
+	return id, nil
+}
@@ -0,0 +1,150 @@
+package tasks
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"final-project/internal/apierror"
+	"final-project/internal/database"
+	"final-project/internal/ics"
+	"final-project/internal/utils"
+)
+
+// maxCalendarSize ограничивает размер импортируемого календаря
+const maxCalendarSize = 1 << 20
+
+// ExceptionHandler обрабатывает запросы к /api/task/exception.
+// GET возвращает даты-исключения задачи и подключённые календари,
+// POST и DELETE добавляют и удаляют дату date.
+func ExceptionHandler(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.ExceptionHandler")
+	defer span.End()
+
+	id, apiErr := queryID(r, "id")
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		exceptions, err := db.TaskExceptions(r.Context(), id)
+		if err != nil {
+			apierror.Write(w, r, dbError(err))
+			return
+		}
+		utils.SendJSON(w, http.StatusOK, exceptions)
+		return
+	}
+
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		apierror.Write(w, r, apierror.Invalid("date", apierror.FieldRequired))
+		return
+	}
+	if _, err := time.Parse(utils.DateFormat, date); err != nil {
+		apierror.Write(w, r, apierror.Invalid("date", apierror.FieldInvalidFormat))
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		err = db.AddException(r.Context(), id, date)
+	case http.MethodDelete:
+		err = db.RemoveException(r.Context(), id, date)
+	default:
+		methodNotAllowed(w, r)
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+
+	utils.SendJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+// TaskCalendarHandler обрабатывает запросы к /api/task/calendar.
+// POST подключает к задаче id календарь исключений calendar, DELETE отключает.
+func TaskCalendarHandler(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.TaskCalendarHandler")
+	defer span.End()
+
+	id, apiErr := queryID(r, "id")
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	calendarID, apiErr := queryID(r, "calendar")
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		err = db.LinkCalendar(r.Context(), id, calendarID)
+	case http.MethodDelete:
+		err = db.UnlinkCalendar(r.Context(), id, calendarID)
+	default:
+		methodNotAllowed(w, r)
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	utils.SendJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+// GetCalendarsHandler обрабатывает запросы к /api/calendars
+func GetCalendarsHandler(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.GetCalendarsHandler")
+	defer span.End()
+
+	calendars, err := db.Calendars(r.Context())
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	utils.SendJSON(w, http.StatusOK, map[string]interface{}{
+		"calendars": calendars,
+	})
+}
+
+// ImportCalendarHandler обрабатывает запросы к /api/calendar/import.
+// Тело запроса — календарь в формате iCalendar, параметр name задаёт имя календаря.
+// Повторный импорт с тем же именем заменяет даты календаря.
+func ImportCalendarHandler(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.ImportCalendarHandler")
+	defer span.End()
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		apierror.Write(w, r, apierror.Invalid("name", apierror.FieldRequired))
+		return
+	}
+
+	events, err := ics.Parse(io.LimitReader(r.Body, maxCalendarSize))
+	if err != nil {
+		apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeInvalidCalendar).Wrap(err))
+		return
+	}
+
+	dates := make([]database.CalendarDate, 0, len(events))
+	for _, e := range events {
+		dates = append(dates, database.CalendarDate{Date: e.Date, Summary: e.Summary})
+	}
+
+	id, err := db.ImportCalendar(r.Context(), name, dates)
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	utils.SendJSON(w, http.StatusOK, map[string]interface{}{
+		"id":    id,
+		"dates": len(dates),
+	})
+}
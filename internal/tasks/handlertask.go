@@ -1,12 +1,15 @@
 package tasks
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
+	"final-project/internal/apierror"
+	"final-project/internal/audit"
 	"final-project/internal/database"
 	"final-project/internal/moduls"
 	"final-project/internal/nextdate"
@@ -15,86 +18,99 @@ import (
 
 // TaskHandler обрабатывает запросы к /api/task.
 func TaskHandler(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.TaskHandler")
+	defer span.End()
+
 	switch r.Method {
 	case http.MethodPost:
 		handleTaskPost(w, r, db)
 	case http.MethodPut:
 		handleTaskPut(w, r, db)
+	case http.MethodPatch:
+		handleTaskPatch(w, r, db)
 	case http.MethodDelete:
 		handleTaskDelete(w, r, db)
 	case http.MethodGet:
-		id := r.URL.Query().Get("id")
-		if id != "" {
-			task, err := db.GetpoID(id)
-			if err != nil {
-				utils.SendError(w, err.Error(), http.StatusNotFound)
-				return
-			}
-			utils.SendJSON(w, http.StatusOK, task)
-		} else {
-			utils.SendError(w, "Invalid request", http.StatusBadRequest)
+		// Без id запрос не указывает на задачу: список задач отдаёт /api/tasks
+		if r.URL.Query().Get("id") == "" {
+			apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeBadRequest))
+			return
+		}
+		id, apiErr := queryID(r, "id")
+		if apiErr != nil {
+			apierror.Write(w, r, apiErr)
+			return
+		}
+		task, err := db.GetpoID(r.Context(), id)
+		if err != nil {
+			apierror.Write(w, r, dbError(err))
+			return
+		}
+		details, err := taskDetails(r.Context(), db, task)
+		if err != nil {
+			apierror.Write(w, r, dbError(err))
 			return
 		}
+		utils.SendJSON(w, http.StatusOK, details)
 	default:
-		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, r)
 	}
 }
 
 // GetTasksHandler получает задачи или все задачи, если фильтры не указаны
 func GetTasksHandler(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.GetTasksHandler")
+	defer span.End()
+
 	search := r.URL.Query().Get("search")
-	log.Printf("GetTasksHandler вызван с параметром search: %s", search)
+	tasks, err := searchTasks(r.Context(), db, search)
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	if r.URL.Query().Get("overdue") == "true" {
+		tasks = overdueTasks(tasks, requestToday(r))
+	}
+	utils.SendJSON(w, http.StatusOK, map[string]interface{}{
+		"tasks": tasks,
+	})
+}
 
+// searchTasks возвращает все задачи, задачи на дату в формате 02.01.2006
+// или задачи, в названии которых встречается строка search
+func searchTasks(ctx context.Context, db *database.DB, search string) ([]moduls.Scheduler, error) {
 	// 1. Сначала проверяем пустой поиск
 	if search == "" {
-		tasks, err := db.ReadTask("")
-		if err != nil {
-			log.Printf("Ошибка при получении всех задач: %v", err)
-			utils.SendError(w, "Ошибка при получении задач", http.StatusInternalServerError)
-			return
-		}
-		utils.SendJSON(w, http.StatusOK, map[string]interface{}{
-			"tasks": tasks,
-		})
-		return
+		return db.ReadTask(ctx, "")
 	}
 
 	// 2. Затем проверяем, является ли поиск датой
 	if isDateFormat(search) {
-		formattedDate := convertDateFormat(search)
-		tasks, err := db.SearchDate(formattedDate)
-		if err != nil {
-			log.Printf("Ошибка при поиске по дате: %v", err)
-			utils.SendError(w, "Ошибка при поиске по дате", http.StatusInternalServerError)
-			return
-		}
-		utils.SendJSON(w, http.StatusOK, map[string]interface{}{
-			"tasks": tasks,
-		})
-		return
+		return db.SearchDate(ctx, convertDateFormat(search))
 	}
+
 	// 3. Если это не дата - значит это текстовый поиск
-	tasks, err := db.Searchtitl(search)
-	if err != nil {
-		log.Printf("Ошибка при поиске по названию: %v", err)
-		utils.SendError(w, "Ошибка при поиске по названию", http.StatusInternalServerError)
-		return
+	return db.Searchtitl(ctx, search)
+}
+
+// overdueTasks оставляет задачи, дата которых уже прошла: today — текущая
+// дата в часовом поясе запроса, поэтому у пользователей в разных часовых
+// поясах просроченными могут оказаться разные задачи
+func overdueTasks(tasks []moduls.Scheduler, today time.Time) []moduls.Scheduler {
+	todayStr := today.Format(utils.DateFormat)
+	overdue := make([]moduls.Scheduler, 0, len(tasks))
+	for _, task := range tasks {
+		if task.Date < todayStr {
+			overdue = append(overdue, task)
+		}
 	}
-	utils.SendJSON(w, http.StatusOK, map[string]interface{}{
-		"tasks": tasks,
-	})
+	return overdue
 }
 
 // Проверка формата даты
 func isDateFormat(s string) bool {
 	_, err := time.Parse("02.01.2006", s)
-	//return err == nil
-	if err != nil {
-		log.Printf("Строка '%s' не является датой: %v", s, err)
-		return false
-	}
-	log.Printf("Строка '%s' является корректной датой", s)
-	return true
+	return err == nil
 }
 
 // Конвертация формата даты
@@ -102,12 +118,9 @@ func convertDateFormat(date string) string {
 	t, err := time.Parse("02.01.2006", date)
 	//return t.Format("20060102")
 	if err != nil {
-		log.Printf("Ошибка конвертации даты '%s': %v", date, err)
 		return ""
 	}
-	formatted := t.Format("20060102")
-	log.Printf("Дата '%s' преобразована в '%s'", date, formatted)
-	return formatted
+	return t.Format("20060102")
 }
 
 // Функция для добавления задачи
@@ -115,152 +128,273 @@ func handleTaskPost(w http.ResponseWriter, r *http.Request, db *database.DB) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	var taskData moduls.Scheduler
 	if err := json.NewDecoder(r.Body).Decode(&taskData); err != nil {
-		utils.SendError(w, "Ошибка при декодировании JSON", http.StatusBadRequest)
+		apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON).Wrap(err))
 		return
 	}
 
-	// Установка даты по умолчанию или проверка формата даты
-	if len(taskData.Date) == 0 {
-		taskData.Date = time.Now().Format(utils.DateFormat)
-	} else {
-		date, err := time.Parse(utils.DateFormat, taskData.Date)
-		if err != nil {
-			utils.SendError(w, "bad data format", http.StatusBadRequest)
-			return
-		}
-
-		if date.Before(time.Now()) {
-			taskData.Date = time.Now().Format(utils.DateFormat)
-		}
-	}
-
-	// Проверка заголовка задачи
-	if len(taskData.Title) == 0 {
-		utils.SendError(w, "invalid title", http.StatusBadRequest)
+	// Проверка полей относительно текущей даты в часовом поясе запроса
+	keepAnchor(&taskData, nil)
+	if fields := validateTask(&taskData, requestToday(r)); len(fields) > 0 {
+		apierror.Write(w, r, apierror.Validation(fields...))
 		return
 	}
 
-	// Проверка формата повтора
-	if len(taskData.Repeat) > 0 {
-		if _, err := nextdate.NextDate(time.Now(), taskData.Date, taskData.Repeat); err != nil {
-			utils.SendError(w, "invalid repeat format", http.StatusBadRequest)
-			return
-		}
-	}
-
 	// Добавление задачи в базу данных
-	taskId, err := db.Create(&taskData)
+	taskId, err := db.Create(r.Context(), &taskData)
 	if err != nil {
-		utils.SendError(w, "failed to create task", http.StatusInternalServerError)
+		apierror.Write(w, r, dbError(err))
 		return
 	}
 
-	// Возвращение ID созданной задачи
-	utils.SendJSON(w, http.StatusCreated, map[string]interface{}{"id": taskId})
+	// Возвращение ID созданной задачи; API v1 всегда отвечал 200, 201 отдаёт только /api/v2
+	utils.SendJSON(w, http.StatusOK, map[string]interface{}{"id": taskId})
 }
 
 // handleTaskPut обновляет задачу
 func handleTaskPut(w http.ResponseWriter, r *http.Request, db *database.DB) {
 	var task moduls.Scheduler
 
-	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-		utils.SendError(w, "JSON deserialization error", http.StatusBadRequest)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeBadRequest).Wrap(err))
+		return
+	}
+	// Поля верхнего уровня нужны, чтобы отличить отсутствующее поле от пустого
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(body, &task); err != nil {
+		apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON).Wrap(err))
 		return
 	}
+	json.Unmarshal(body, &present)
 
-	// проверка id
+	// проверка id и даты: при обновлении они обязательны
+	var fields []apierror.FieldError
 	if len(task.ID) == 0 {
-		utils.SendError(w, "invalid id", http.StatusBadRequest)
-		return
+		fields = append(fields, apierror.Field("id", apierror.FieldRequired))
+	} else if _, err := strconv.Atoi(task.ID); err != nil {
+		fields = append(fields, apierror.Field("id", apierror.FieldInvalidFormat))
+	}
+	if len(task.Date) == 0 {
+		fields = append(fields, apierror.Field("date", apierror.FieldRequired))
 	}
 
-	// проверка id на число
-	if _, err := strconv.Atoi(task.ID); err != nil {
-		utils.SendError(w, "invalid id", http.StatusBadRequest)
-		return
+	var current *moduls.Scheduler
+	if len(fields) == 0 {
+		stored, err := db.GetpoID(r.Context(), task.ID)
+		if err != nil {
+			apierror.Write(w, r, dbError(err))
+			return
+		}
+		current = &stored
+	}
+	// Веб-интерфейс не передаёт условия окончания повтора, поэтому
+	// отсутствующие в теле until и count остаются прежними. Без правила
+	// повтора условия окончания теряют смысл и не сохраняются.
+	if current != nil && task.Repeat != "" {
+		if _, ok := present["until"]; !ok {
+			task.Until = current.Until
+		}
+		if _, ok := present["count"]; !ok {
+			task.Count = current.Count
+		}
 	}
+	keepAnchor(&task, current)
 
-	// проверка даты
-	parseDate, err := time.Parse(utils.DateFormat, task.Date)
-	if err != nil {
-		utils.SendError(w, "invalid date format", http.StatusBadRequest)
+	// проверка остальных полей относительно текущей даты в часовом поясе запроса
+	fields = append(fields, validateTask(&task, requestToday(r))...)
+	if len(fields) > 0 {
+		apierror.Write(w, r, apierror.Validation(fields...))
 		return
 	}
-	if parseDate.Before(time.Now()) {
-		task.Date = time.Now().Format(utils.DateFormat)
-	}
 
-	// проверка заголовка
-	if len(task.Title) == 0 {
-		utils.SendError(w, "invalid title", http.StatusBadRequest)
+	// обновление задачи
+	if err := db.Update(r.Context(), &task); err != nil {
+		apierror.Write(w, r, dbError(err))
 		return
 	}
 
-	// проверка формата повтора
-	if len(task.Repeat) > 0 {
-		if _, err := nextdate.NextDate(time.Now(), task.Date, task.Repeat); err != nil {
-			utils.SendError(w, "invalid repeat format", http.StatusBadRequest)
-			return
-		}
+	utils.SendJSON(w, http.StatusOK, task)
+}
+
+// handleTaskPatch частично обновляет задачу id. Тело — JSON Merge Patch
+// (RFC 7396): переданные поля заменяются, null сбрасывает поле, остальные
+// не меняются. Проверяется задача после применения патча.
+func handleTaskPatch(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	id, apiErr := queryID(r, "id")
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	current, err := db.GetpoID(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
 	}
 
-	// обновление задачи
-	err = db.Update(&task)
+	patch, err := io.ReadAll(r.Body)
 	if err != nil {
-		utils.SendError(w, "failed to update task", http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeBadRequest).Wrap(err))
+		return
+	}
+	task := current
+	if apiErr := mergePatchError(applyMergePatch(&task, patch)); apiErr != nil {
+		apierror.Write(w, r, apiErr)
 		return
 	}
+	// Идентификатор патчем не меняется
+	task.ID = current.ID
+	keepAnchor(&task, &current)
 
+	if fields := validateTask(&task, requestToday(r)); len(fields) > 0 {
+		apierror.Write(w, r, apierror.Validation(fields...))
+		return
+	}
+	if err := db.Update(r.Context(), &task); err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
 	utils.SendJSON(w, http.StatusOK, task)
 }
 
 // HandleTaskDone обрабатывает запрос на выполнение задачи
 func HandleTaskDone(w http.ResponseWriter, r *http.Request, db *database.DB) {
-	log.Println("API: Завершение задачи")
+	r, span := startSpan(r, "tasks.HandleTaskDone")
+	defer span.End()
+
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-	id := r.URL.Query().Get("id")
-	task, err := db.GetpoID(id)
+	id, apiErr := queryID(r, "id")
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	task, err := db.GetpoID(r.Context(), id)
 	if err != nil {
-		utils.SendError(w, "failed to get task by id", http.StatusInternalServerError)
+		apierror.Write(w, r, dbError(err))
 		return
 	}
 
-	if task.Repeat == "" {
-		err = db.Delete(task.ID)
+	_, blockers, apiErr := completeTask(r, db, task)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	if len(blockers) > 0 {
+		apierror.Write(w, r, apierror.New(http.StatusConflict, apierror.CodeTaskBlocked).
+			With("blocked_by", blockers))
+		return
+	}
+
+	// Возвращаем пустой ответ
+	utils.SendJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+// completeTask выполняет задачу: разовая задача удаляется, повторяющаяся
+// переносится на следующую дату. Возвращает задачу с новой датой или nil,
+// если задача удалена. Пока не выполнены блокирующие задачи, задача
+// не выполняется и возвращается их список, если только не передан параметр force=true.
+func completeTask(r *http.Request, db *database.DB, task moduls.Scheduler) (*moduls.Scheduler, []moduls.Scheduler, *apierror.Error) {
+	if r.URL.Query().Get("force") != "true" {
+		blockers, err := db.OpenBlockers(r.Context(), task)
 		if err != nil {
-			utils.SendError(w, "failed to delete task", http.StatusInternalServerError)
-			return
+			return nil, nil, dbError(err)
 		}
-	} else {
-		task.Date, err = nextdate.NextDate(time.Now(), task.Date, task.Repeat)
-		if err != nil {
-			utils.SendError(w, "failed to get next date", http.StatusInternalServerError)
-			return
+		if len(blockers) > 0 {
+			return nil, blockers, nil
 		}
-		// Обновляем задачу с новой датой
-		err = db.Update(&task)
-		if err != nil {
-			utils.SendError(w, "failed to update task", http.StatusInternalServerError)
-			return
+	}
+
+	// Удаление или перенос задачи записываются в журнал аудита как выполнение
+	ctx := audit.WithAction(r.Context(), audit.ActionDone)
+	if task.Repeat == "" {
+		if err := db.Delete(ctx, task.ID); err != nil {
+			return nil, nil, dbError(err)
 		}
+		return nil, nil, nil
 	}
+	next, err := advanceOccurrence(ctx, db, task, requestToday(r))
+	if err != nil {
+		return nil, nil, dbError(err)
+	}
+	return next, nil, nil
+}
 
-	// Возвращаем пустой ответ
-	utils.SendJSON(w, http.StatusOK, map[string]interface{}{})
+// validateTask проверяет поля задачи и возвращает ошибки всех неверных полей.
+// Пустая дата заменяется сегодняшней, прошедшая переносится на сегодня.
+func validateTask(task *moduls.Scheduler, today time.Time) []apierror.FieldError {
+	var fields []apierror.FieldError
+
+	// Установка даты по умолчанию или проверка формата даты
+	dateValid := true
+	if len(task.Date) == 0 {
+		task.Date = today.Format(utils.DateFormat)
+	} else if date, err := time.Parse(utils.DateFormat, task.Date); err != nil {
+		fields = append(fields, apierror.Field("date", apierror.FieldInvalidFormat))
+		dateValid = false
+	} else if date.Before(today) {
+		task.Date = today.Format(utils.DateFormat)
+	}
+
+	// Проверка заголовка задачи
+	if len(task.Title) == 0 {
+		fields = append(fields, apierror.Field("title", apierror.FieldRequired))
+	}
+
+	// Проверка формата повтора; без корректной даты правило проверить нельзя
+	if len(task.Repeat) > 0 && dateValid {
+		if _, err := nextdate.NextDate(today, task.Date, task.Repeat); err != nil {
+			fields = append(fields, apierror.Field("repeat", apierror.FieldInvalidFormat))
+		}
+	}
+
+	// Проверка условий окончания повтора
+	return append(fields, validateRecurrence(task)...)
+}
+
+// keepAnchor заменяет исходную дату отложенного повторения, пришедшую от
+// клиента, сохранённой: её задаёт только сервер при переносе повторения.
+// current — сохранённая задача, nil при создании. Если дата задачи или
+// правило повтора изменились, серия считается от новой даты.
+func keepAnchor(task *moduls.Scheduler, current *moduls.Scheduler) {
+	task.Anchor = ""
+	if current != nil && task.Date == current.Date && task.Repeat == current.Repeat {
+		task.Anchor = current.Anchor
+	}
+}
+
+// validateRecurrence проверяет условия окончания повтора задачи
+func validateRecurrence(task *moduls.Scheduler) []apierror.FieldError {
+	var fields []apierror.FieldError
+	if len(task.Repeat) == 0 {
+		if task.Until != "" {
+			fields = append(fields, apierror.Field("until", apierror.FieldRequiresRepeat))
+		}
+		if task.Count != 0 {
+			fields = append(fields, apierror.Field("count", apierror.FieldRequiresRepeat))
+		}
+		return fields
+	}
+	if task.Until != "" {
+		if _, err := time.Parse(utils.DateFormat, task.Until); err != nil {
+			fields = append(fields, apierror.Field("until", apierror.FieldInvalidFormat))
+		}
+	}
+	if task.Count < 0 {
+		fields = append(fields, apierror.Field("count", apierror.FieldOutOfRange))
+	}
+	return fields
 }
 
 // handleTaskDelete удаляет задачу
 func handleTaskDelete(w http.ResponseWriter, r *http.Request, db *database.DB) {
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		utils.SendError(w, "ID не указан", http.StatusBadRequest)
+	id, apiErr := queryID(r, "id")
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
 		return
 	}
 
-	err := db.Delete(id)
-	if err != nil {
-		utils.SendError(w, "Ошибка при удалении задачи", http.StatusInternalServerError)
+	if err := db.Delete(r.Context(), id); err != nil {
+		apierror.Write(w, r, dbError(err))
 		return
 	}
 
@@ -1,30 +1,70 @@
 package tasks
 
 import (
-	"log"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"final-project/internal/apierror"
+	"final-project/internal/logger"
+	"final-project/internal/moduls"
 	"final-project/internal/nextdate"
 	"final-project/internal/utils"
 )
 
+const (
+	// defaultPreviewCount количество дат в предпросмотре по умолчанию (параметр count)
+	defaultPreviewCount = 10
+	// maxPreviewCount максимальное количество дат в предпросмотре
+	maxPreviewCount = 100
+)
+
 // NextDateHandler обрабатывает запросы к /api/nextdate.
 func NextDateHandler(w http.ResponseWriter, r *http.Request) {
-	// Получаем параметр "now" из запроса и парсим его
-	now, err := time.Parse(utils.DateFormat, r.FormValue("now"))
-	if err != nil {
-		utils.SendError(w, err.Error(), http.StatusBadRequest)
-		return
+	r, span := startSpan(r, "tasks.NextDateHandler")
+	defer span.End()
+
+	// Параметр "now" необязателен: по умолчанию — сегодня в часовом поясе запроса
+	now := requestToday(r)
+	if value := r.FormValue("now"); value != "" {
+		var err error
+		now, err = time.Parse(utils.DateFormat, value)
+		if err != nil {
+			apierror.Write(w, r, apierror.Invalid("now", apierror.FieldInvalidFormat))
+			return
+		}
 	}
 	// Получаем параметры "date" и "repeat" из запроса
 	date := r.FormValue("date")
 	repeat := r.FormValue("repeat")
 
+	// Необязательные условия окончания повтора
+	opts, apiErr := nextDateOptions(r)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	if count := r.FormValue("count"); count != "" {
+		var err error
+		opts.Count, err = strconv.Atoi(count)
+		if err != nil {
+			apierror.Write(w, r, apierror.Invalid("count", apierror.FieldInvalidFormat))
+			return
+		}
+	}
+
 	// Вычисляем следующую дату с помощью функции NextDate
-	nextDate, err := nextdate.NextDate(now, date, repeat)
+	nextDate, err := nextdate.NextDateWithOptions(now, date, repeat, opts)
+	if errors.Is(err, nextdate.ErrSeriesEnded) {
+		// Серия повторов закончилась: следующей даты нет
+		w.Header().Set("X-Series-Ended", "true")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
 	if err != nil {
-		utils.SendError(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, r, nextDateError(err))
 		return
 	}
 	// Возвращаем результат в ответе
@@ -32,6 +72,124 @@ func NextDateHandler(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write([]byte(nextDate))
 
 	if err != nil {
-		log.Printf("writing tasks data error: %v", err)
+		logger.FromContext(r.Context()).Error("Ошибка записи ответа", "error", err)
+	}
+}
+
+// NextDatePreviewHandler обрабатывает запросы к /api/nextdate/preview.
+// Возвращает count ближайших дат повтора и описание правила. Серия может
+// закончиться раньше по условиям окончания until и occurrences (оставшееся
+// количество повторений, как count в /api/nextdate) или из-за дат-исключений except.
+func NextDatePreviewHandler(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "tasks.NextDatePreviewHandler")
+	defer span.End()
+
+	now := requestToday(r)
+	if value := r.FormValue("now"); value != "" {
+		var err error
+		now, err = time.Parse(utils.DateFormat, value)
+		if err != nil {
+			apierror.Write(w, r, apierror.Invalid("now", apierror.FieldInvalidFormat))
+			return
+		}
+	}
+	date := r.FormValue("date")
+	if date == "" {
+		date = now.Format(utils.DateFormat)
+	}
+	repeat := r.FormValue("repeat")
+
+	limit := defaultPreviewCount
+	if value := r.FormValue("count"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			apierror.Write(w, r, apierror.Invalid("count", apierror.FieldInvalidFormat))
+			return
+		}
+		if limit < 1 || limit > maxPreviewCount {
+			apierror.Write(w, r, apierror.Invalid("count", apierror.FieldOutOfRange))
+			return
+		}
+	}
+	opts, apiErr := nextDateOptions(r)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	if value := r.FormValue("occurrences"); value != "" {
+		var err error
+		opts.Count, err = strconv.Atoi(value)
+		if err != nil {
+			apierror.Write(w, r, apierror.Invalid("occurrences", apierror.FieldInvalidFormat))
+			return
+		}
+		if opts.Count < 0 {
+			apierror.Write(w, r, apierror.Invalid("occurrences", apierror.FieldOutOfRange))
+			return
+		}
+	}
+
+	preview := moduls.NextDatePreview{Dates: make([]string, 0, limit)}
+	for len(preview.Dates) < limit {
+		next, err := nextdate.NextDateWithOptions(now, date, repeat, opts)
+		if errors.Is(err, nextdate.ErrSeriesEnded) {
+			preview.Ended = true
+			break
+		}
+		if err != nil {
+			apierror.Write(w, r, nextDateError(err))
+			return
+		}
+		preview.Dates = append(preview.Dates, next)
+
+		// Следующая дата ищется после найденной, как после выполнения задачи:
+		// оставшихся повторений становится меньше
+		now, _ = time.Parse(utils.DateFormat, next)
+		date = next
+		if opts.Count > 0 {
+			opts.Count--
+		}
+	}
+
+	description, err := nextdate.Describe(repeat)
+	if err != nil {
+		apierror.Write(w, r, apierror.Invalid("repeat", apierror.FieldInvalidFormat).Wrap(err))
+		return
+	}
+	preview.Description = description
+
+	utils.SendJSON(w, http.StatusOK, preview)
+}
+
+// nextDateError переводит ошибку вычисления даты в ошибку параметра запроса
+func nextDateError(err error) *apierror.Error {
+	switch {
+	case errors.Is(err, nextdate.ErrInvalidDate):
+		return apierror.Invalid("date", apierror.FieldInvalidFormat).Wrap(err)
+	case errors.Is(err, nextdate.ErrInvalidCount):
+		return apierror.Invalid("count", apierror.FieldOutOfRange).Wrap(err)
+	case errors.Is(err, nextdate.ErrInvalidUntil):
+		return apierror.Invalid("until", apierror.FieldInvalidFormat).Wrap(err)
+	case errors.Is(err, nextdate.ErrAllExcluded):
+		return apierror.Invalid("except", apierror.FieldInvalidValue).Wrap(err)
+	}
+	return apierror.Invalid("repeat", apierror.FieldInvalidFormat).Wrap(err)
+}
+
+// nextDateOptions читает из запроса дату окончания повтора until
+// и даты-исключения except, перечисленные через запятую
+func nextDateOptions(r *http.Request) (nextdate.Options, *apierror.Error) {
+	opts := nextdate.Options{Until: r.FormValue("until")}
+	if except := r.FormValue("except"); except != "" {
+		opts.Exceptions = make(map[string]bool)
+		for _, d := range strings.Split(except, ",") {
+			d = strings.TrimSpace(d)
+			if _, err := time.Parse(utils.DateFormat, d); err != nil {
+				return opts, apierror.Invalid("except", apierror.FieldInvalidFormat).With("value", d)
+			}
+			opts.Exceptions[d] = true
+		}
 	}
+	return opts, nil
 }
@@ -0,0 +1,178 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"final-project/internal/apierror"
+	"final-project/internal/audit"
+	"final-project/internal/database"
+	"final-project/internal/moduls"
+	"final-project/internal/nextdate"
+	"final-project/internal/utils"
+)
+
+// maxSnoozeDays максимальное количество дней, на которое можно отложить задачу
+const maxSnoozeDays = 400
+
+// SkipTaskHandler обрабатывает запросы к /api/task/skip.
+// Повторяющаяся задача переносится на следующую дату без отметки о выполнении.
+func SkipTaskHandler(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.SkipTaskHandler")
+	defer span.End()
+
+	id, apiErr := queryID(r, "id")
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	task, err := db.GetpoID(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	if task.Repeat == "" {
+		apierror.Write(w, r, apierror.New(http.StatusUnprocessableEntity, apierror.CodeTaskNotRecurring))
+		return
+	}
+
+	next, err := advanceOccurrence(audit.WithAction(r.Context(), audit.ActionSkip), db, task, requestToday(r))
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	// Пропущенное повторение было последним, задача удалена
+	if next == nil {
+		utils.SendJSON(w, http.StatusOK, map[string]interface{}{})
+		return
+	}
+	utils.SendJSON(w, http.StatusOK, next)
+}
+
+// SnoozeTaskHandler обрабатывает запросы к /api/task/snooze.
+// Откладывает только текущее повторение на days дней или до даты until;
+// следующие даты повторяющейся задачи считаются от исходной даты.
+func SnoozeTaskHandler(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.SnoozeTaskHandler")
+	defer span.End()
+
+	id, apiErr := queryID(r, "id")
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	task, err := db.GetpoID(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+
+	current, err := time.Parse(utils.DateFormat, task.Date)
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal(err))
+		return
+	}
+
+	var snoozed time.Time
+	days, until := r.URL.Query().Get("days"), r.URL.Query().Get("until")
+	switch {
+	case days != "" && until != "":
+		apierror.Write(w, r, apierror.Validation(
+			apierror.Field("days", apierror.FieldConflict),
+			apierror.Field("until", apierror.FieldConflict),
+		))
+		return
+	case days != "":
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			apierror.Write(w, r, apierror.Invalid("days", apierror.FieldInvalidFormat))
+			return
+		}
+		if n < 1 || n > maxSnoozeDays {
+			apierror.Write(w, r, apierror.Invalid("days", apierror.FieldOutOfRange))
+			return
+		}
+		snoozed = current.AddDate(0, 0, n)
+	case until != "":
+		snoozed, err = time.Parse(utils.DateFormat, until)
+		if err != nil {
+			apierror.Write(w, r, apierror.Invalid("until", apierror.FieldInvalidFormat))
+			return
+		}
+		if !snoozed.After(current) {
+			apierror.Write(w, r, apierror.Invalid("until", apierror.FieldOutOfRange))
+			return
+		}
+	default:
+		apierror.Write(w, r, apierror.Validation(
+			apierror.Field("days", apierror.FieldRequired),
+			apierror.Field("until", apierror.FieldRequired),
+		))
+		return
+	}
+
+	// Запоминаем исходную дату, чтобы серия не сдвинулась
+	if task.Repeat != "" && task.Anchor == "" {
+		task.Anchor = task.Date
+	}
+	task.Date = snoozed.Format(utils.DateFormat)
+
+	if err := db.Update(audit.WithAction(r.Context(), audit.ActionSnooze), &task); err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	utils.SendJSON(w, http.StatusOK, task)
+}
+
+// advanceOccurrence переносит повторяющуюся задачу на следующую дату.
+// Дата считается от исходной даты отложенного повторения, если она есть,
+// и всегда оказывается позже текущей даты задачи.
+// Если повторения закончились, задача удаляется и возвращается nil.
+func advanceOccurrence(ctx context.Context, db *database.DB, task moduls.Scheduler, now time.Time) (*moduls.Scheduler, error) {
+	base := task.Date
+	if task.Anchor != "" {
+		base = task.Anchor
+	}
+
+	// Следующая дата должна быть позже текущей даты задачи,
+	// даже если задача выполняется заранее
+	if current, err := time.Parse(utils.DateFormat, task.Date); err == nil && current.After(now) {
+		now = current
+	}
+
+	exceptions, err := db.ExceptionDates(ctx, task.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task exceptions: %w", err)
+	}
+
+	next, err := nextdate.NextDateWithOptions(now, base, task.Repeat, nextdate.Options{
+		Until:      task.Until,
+		Count:      task.Count,
+		Exceptions: exceptions,
+	})
+	switch {
+	case errors.Is(err, nextdate.ErrSeriesEnded):
+		// Повторения закончились — удаляем задачу так же, как разовую
+		if err := db.Delete(ctx, task.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete task: %w", err)
+		}
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get next date: %w", err)
+	}
+
+	task.Date = next
+	task.Anchor = ""
+	if task.Count > 0 {
+		task.Count--
+	}
+	// Обновляем задачу с новой датой
+	if err := db.Update(ctx, &task); err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+	return &task, nil
+}
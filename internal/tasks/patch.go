@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// errPatchNotObject возвращается, если патч не является JSON-объектом
+var errPatchNotObject = errors.New("патч должен быть JSON-объектом")
+
+// applyMergePatch применяет к значению target патч в формате
+// JSON Merge Patch (RFC 7396) и записывает результат обратно в target.
+// Поле со значением null в патче сбрасывает поле к значению по умолчанию.
+func applyMergePatch[T any](target *T, patch []byte) error {
+	var patchValue interface{}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return err
+	}
+	if _, ok := patchValue.(map[string]interface{}); !ok {
+		return errPatchNotObject
+	}
+
+	original, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(original, &doc); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(mergePatch(doc, patchValue))
+	if err != nil {
+		return err
+	}
+	// Результат собирается заново, чтобы удалённые поля получили нулевые значения
+	var result T
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return err
+	}
+	*target = result
+	return nil
+}
+
+// mergePatch реализует алгоритм MergePatch из RFC 7396
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+	for name, value := range patchObj {
+		if value == nil {
+			delete(targetObj, name)
+			continue
+		}
+		targetObj[name] = mergePatch(targetObj[name], value)
+	}
+	return targetObj
+}
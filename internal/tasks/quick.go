@@ -0,0 +1,71 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"final-project/internal/apierror"
+	"final-project/internal/database"
+	"final-project/internal/moduls"
+	"final-project/internal/quickadd"
+	"final-project/internal/utils"
+)
+
+// QuickTaskHandler обрабатывает запросы к /api/task/quick.
+// Разбирает фразу вроде "Standup every weekday" в задачу и возвращает
+// результат для подтверждения или, если create=true, сразу создаёт задачу.
+func QuickTaskHandler(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.QuickTaskHandler")
+	defer span.End()
+
+	var req moduls.QuickTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON).Wrap(err))
+		return
+	}
+
+	today := requestToday(r)
+	if strings.TrimSpace(req.Text) == "" {
+		apierror.Write(w, r, apierror.Invalid("text", apierror.FieldRequired))
+		return
+	}
+	result, err := quickadd.Parse(req.Text, today)
+	var unsupported *quickadd.UnsupportedError
+	if errors.As(err, &unsupported) {
+		var fields []apierror.FieldError
+		if len(unsupported.Tags) > 0 {
+			fields = append(fields, apierror.Field("tags", apierror.FieldUnsupported))
+		}
+		if len(unsupported.Priority) > 0 {
+			fields = append(fields, apierror.Field("priority", apierror.FieldUnsupported))
+		}
+		apierror.Write(w, r, apierror.Validation(fields...).Wrap(err))
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, apierror.Invalid("text", apierror.FieldInvalidValue).Wrap(err))
+		return
+	}
+
+	// Задача проверяется так же, как при обычном создании:
+	// прошедшая дата переносится на сегодня
+	if fields := validateTask(&result.Task, today); len(fields) > 0 {
+		apierror.Write(w, r, apierror.Validation(fields...))
+		return
+	}
+
+	if !req.Create {
+		utils.SendJSON(w, http.StatusOK, result)
+		return
+	}
+
+	id, err := db.Create(r.Context(), &result.Task)
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	result.ID = id
+	utils.SendJSON(w, http.StatusCreated, result)
+}
@@ -0,0 +1,61 @@
+package tasks
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"final-project/internal/apierror"
+	"final-project/internal/audit"
+	"final-project/internal/database"
+	"final-project/internal/moduls"
+	"final-project/internal/timezone"
+	"final-project/internal/utils"
+)
+
+// SettingsHandler обрабатывает запросы к /api/settings.
+// Настройки хранятся для автора запроса, как он записывается в журнал аудита,
+// поэтому без токена их нет.
+// GET возвращает сохранённый часовой пояс и часовой пояс запроса,
+// PUT сохраняет часовой пояс; пустая строка возвращает часовой пояс экземпляра.
+func SettingsHandler(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.SettingsHandler")
+	defer span.End()
+
+	actor := audit.Actor(r)
+	if actor == audit.Anonymous {
+		apierror.Write(w, r, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		name, err := db.UserTimezone(r.Context(), actor)
+		if err != nil {
+			apierror.Write(w, r, dbError(err))
+			return
+		}
+		utils.SendJSON(w, http.StatusOK, moduls.Settings{
+			Timezone:  name,
+			Effective: timezone.FromContext(r.Context()).String(),
+		})
+	case http.MethodPut:
+		var settings moduls.Settings
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON).Wrap(err))
+			return
+		}
+		if settings.Timezone != "" {
+			if _, err := time.LoadLocation(settings.Timezone); err != nil {
+				apierror.Write(w, r, apierror.Invalid("timezone", apierror.FieldInvalidValue).Wrap(err))
+				return
+			}
+		}
+		if err := db.SetUserTimezone(r.Context(), actor, settings.Timezone); err != nil {
+			apierror.Write(w, r, dbError(err))
+			return
+		}
+		utils.SendJSON(w, http.StatusOK, moduls.Settings{Timezone: settings.Timezone})
+	default:
+		methodNotAllowed(w, r)
+	}
+}
@@ -0,0 +1,16 @@
+package tasks
+
+import (
+	"net/http"
+
+	"final-project/internal/tracing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan начинает дочерний span обработчика; его контекст передаётся
+// дальше через возвращённый запрос, чтобы spans базы данных стали дочерними
+func startSpan(r *http.Request, name string) (*http.Request, trace.Span) {
+	ctx, span := tracing.Start(r.Context(), name)
+	return r.WithContext(ctx), span
+}
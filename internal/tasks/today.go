@@ -0,0 +1,16 @@
+package tasks
+
+import (
+	"net/http"
+	"time"
+
+	"final-project/internal/clock"
+	"final-project/internal/timezone"
+)
+
+// requestToday возвращает текущую дату по часам запроса в его часовом поясе.
+// Дата представлена полуночью UTC, как и разобранные даты задач,
+// поэтому её можно напрямую сравнивать с ними и передавать в NextDate.
+func requestToday(r *http.Request) time.Time {
+	return timezone.Today(clock.FromContext(r.Context()).Now(), timezone.FromContext(r.Context()))
+}
@@ -0,0 +1,256 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/go-chi/chi"
+
+	"final-project/internal/apierror"
+	"final-project/internal/database"
+	"final-project/internal/moduls"
+	"final-project/internal/utils"
+)
+
+// Обработчики API v2: задачи адресуются путём /api/v2/tasks/{id},
+// а идентификаторы передаются числами.
+
+// ListTasksV2 обрабатывает GET /api/v2/tasks; параметр search работает как в /api/tasks
+func ListTasksV2(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.ListTasksV2")
+	defer span.End()
+
+	tasks, err := searchTasks(r.Context(), db, r.URL.Query().Get("search"))
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	if r.URL.Query().Get("overdue") == "true" {
+		tasks = overdueTasks(tasks, requestToday(r))
+	}
+	utils.SendJSON(w, http.StatusOK, moduls.TaskV2List{Tasks: moduls.NewTasksV2(tasks)})
+}
+
+// tasksV2Path путь коллекции задач API v2; адрес задачи — tasksV2Path/{id}
+const tasksV2Path = "/api/v2/tasks"
+
+// CreateTaskV2 обрабатывает POST /api/v2/tasks и возвращает созданную задачу
+func CreateTaskV2(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.CreateTaskV2")
+	defer span.End()
+
+	var input moduls.TaskV2
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON).Wrap(err))
+		return
+	}
+	input.ID = 0
+	task := input.Scheduler()
+	keepAnchor(&task, nil)
+
+	if fields := validateTask(&task, requestToday(r)); len(fields) > 0 {
+		apierror.Write(w, r, apierror.Validation(fields...))
+		return
+	}
+
+	id, err := db.Create(r.Context(), &task)
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	task.ID = strconv.Itoa(id)
+
+	w.Header().Set("Location", path.Join(tasksV2Path, task.ID))
+	utils.SendJSON(w, http.StatusCreated, moduls.NewTaskV2(task))
+}
+
+// GetTaskV2 обрабатывает GET /api/v2/tasks/{id}
+func GetTaskV2(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.GetTaskV2")
+	defer span.End()
+
+	task, apiErr := taskFromPath(r, db)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	details, err := taskDetails(r.Context(), db, task)
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	utils.SendJSON(w, http.StatusOK, moduls.TaskV2Details{
+		TaskV2:    moduls.NewTaskV2(details.Scheduler),
+		BlockedBy: moduls.NewTasksV2(details.BlockedBy),
+		Blocks:    moduls.NewTasksV2(details.Blocks),
+	})
+}
+
+// ReplaceTaskV2 обрабатывает PUT /api/v2/tasks/{id}: задача заменяется целиком,
+// идентификатор берётся из пути
+func ReplaceTaskV2(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.ReplaceTaskV2")
+	defer span.End()
+
+	id, apiErr := pathID(r)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	var input moduls.TaskV2
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON).Wrap(err))
+		return
+	}
+	task := input.Scheduler()
+	task.ID = id
+
+	current, err := db.GetpoID(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	keepAnchor(&task, &current)
+
+	var fields []apierror.FieldError
+	if len(task.Date) == 0 {
+		fields = append(fields, apierror.Field("date", apierror.FieldRequired))
+	}
+	updateTaskV2(w, r, db, task, fields)
+}
+
+// PatchTaskV2 обрабатывает PATCH /api/v2/tasks/{id}: тело — JSON Merge Patch
+// (RFC 7396), проверяется задача после применения патча
+func PatchTaskV2(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.PatchTaskV2")
+	defer span.End()
+
+	current, apiErr := taskFromPath(r, db)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeBadRequest).Wrap(err))
+		return
+	}
+	task := moduls.NewTaskV2(current)
+	if apiErr := mergePatchError(applyMergePatch(&task, patch)); apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	// Идентификатор патчем не меняется
+	merged := task.Scheduler()
+	merged.ID = current.ID
+	keepAnchor(&merged, &current)
+	updateTaskV2(w, r, db, merged, nil)
+}
+
+// DeleteTaskV2 обрабатывает DELETE /api/v2/tasks/{id}
+func DeleteTaskV2(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.DeleteTaskV2")
+	defer span.End()
+
+	id, apiErr := pathID(r)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	if err := db.Delete(r.Context(), id); err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CompleteTaskV2 обрабатывает POST /api/v2/tasks/{id}/complete.
+// Возвращает задачу с новой датой или 204, если задача была разовой
+// или её повторения закончились.
+func CompleteTaskV2(w http.ResponseWriter, r *http.Request, db *database.DB) {
+	r, span := startSpan(r, "tasks.CompleteTaskV2")
+	defer span.End()
+
+	task, apiErr := taskFromPath(r, db)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+
+	next, blockers, apiErr := completeTask(r, db, task)
+	if apiErr != nil {
+		apierror.Write(w, r, apiErr)
+		return
+	}
+	if len(blockers) > 0 {
+		apierror.Write(w, r, apierror.New(http.StatusConflict, apierror.CodeTaskBlocked).
+			With("blocked_by", moduls.NewTasksV2(blockers)))
+		return
+	}
+	if next == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	utils.SendJSON(w, http.StatusOK, moduls.NewTaskV2(*next))
+}
+
+// updateTaskV2 проверяет и сохраняет задачу, возвращая её в ответе.
+// fields — ошибки, найденные обработчиком до общей проверки.
+func updateTaskV2(w http.ResponseWriter, r *http.Request, db *database.DB, task moduls.Scheduler, fields []apierror.FieldError) {
+	fields = append(fields, validateTask(&task, requestToday(r))...)
+	if len(fields) > 0 {
+		apierror.Write(w, r, apierror.Validation(fields...))
+		return
+	}
+	if err := db.Update(r.Context(), &task); err != nil {
+		apierror.Write(w, r, dbError(err))
+		return
+	}
+	utils.SendJSON(w, http.StatusOK, moduls.NewTaskV2(task))
+}
+
+// pathID читает числовой идентификатор задачи из пути
+func pathID(r *http.Request) (string, *apierror.Error) {
+	id := chi.URLParam(r, "id")
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return "", apierror.Invalid("id", apierror.FieldInvalidFormat)
+	}
+	return id, nil
+}
+
+// taskFromPath загружает задачу по идентификатору из пути
+func taskFromPath(r *http.Request, db *database.DB) (moduls.Scheduler, *apierror.Error) {
+	id, apiErr := pathID(r)
+	if apiErr != nil {
+		return moduls.Scheduler{}, apiErr
+	}
+	task, err := db.GetpoID(r.Context(), id)
+	if err != nil {
+		return moduls.Scheduler{}, dbError(err)
+	}
+	return task, nil
+}
+
+// mergePatchError переводит ошибку применения патча в ошибку API
+func mergePatchError(err error) *apierror.Error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, errPatchNotObject):
+		return apierror.Invalid("body", apierror.FieldInvalidFormat).Wrap(err)
+	case errors.As(err, &typeErr):
+		return apierror.Invalid(typeErr.Field, apierror.FieldInvalidFormat).Wrap(err)
+	case errors.As(err, &syntaxErr):
+		return apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON).Wrap(err)
+	}
+	return apierror.Internal(err)
+}
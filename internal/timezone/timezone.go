@@ -0,0 +1,131 @@
+package timezone
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"final-project/internal/apierror"
+	"final-project/internal/logger"
+	"final-project/internal/utils"
+)
+
+// Header заголовок запроса с часовым поясом пользователя (например, Europe/Moscow)
+const Header = "X-Timezone"
+
+// contextKey тип ключа контекста, чтобы избежать пересечений с другими пакетами
+type contextKey struct{}
+
+// Load загружает часовой пояс по имени из базы IANA.
+// Пустое имя означает локальный часовой пояс сервера.
+func Load(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// Stored возвращает сохранённый часовой пояс пользователя, выполняющего
+// запрос, или пустую строку, если пользователь его не задавал
+type Stored func(r *http.Request) (string, error)
+
+// location часовой пояс запроса. Сохранённая настройка пользователя
+// читается только при первом обращении, поэтому запросы, которым часовой
+// пояс не нужен, не обращаются к базе данных.
+type location struct {
+	once    sync.Once
+	loc     *time.Location
+	resolve func() *time.Location
+}
+
+// get возвращает часовой пояс, при необходимости читая настройку
+func (l *location) get() *time.Location {
+	l.once.Do(func() {
+		if l.resolve != nil {
+			l.loc = l.resolve()
+			l.resolve = nil
+		}
+	})
+	return l.loc
+}
+
+// Middleware определяет часовой пояс запроса и сохраняет его в контексте.
+// Часовой пояс берётся из заголовка X-Timezone, параметра tz или cookie tz,
+// затем из настройки пользователя stored (если задана), а если он нигде
+// не указан — используется часовой пояс экземпляра.
+func Middleware(instance *time.Location, stored Stored) func(http.Handler) http.Handler {
+	if instance == nil {
+		instance = time.Local
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			loc := &location{loc: instance}
+			if name := requestTimezone(r); name != "" {
+				var err error
+				loc.loc, err = time.LoadLocation(name)
+				if err != nil {
+					apierror.Write(w, r, apierror.Invalid("tz", apierror.FieldInvalidValue).Wrap(err))
+					return
+				}
+			} else if stored != nil {
+				loc.resolve = func() *time.Location { return storedLocation(r, stored, instance) }
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, loc)))
+		})
+	}
+}
+
+// storedLocation возвращает сохранённый часовой пояс пользователя.
+// Если настройку не удалось прочитать, запрос выполняется в часовом поясе
+// экземпляра: ошибка настройки не должна мешать работе с задачами.
+func storedLocation(r *http.Request, stored Stored, instance *time.Location) *time.Location {
+	name, err := stored(r)
+	if err != nil {
+		logger.FromContext(r.Context()).Warn("Ошибка чтения часового пояса пользователя", "error", err)
+		return instance
+	}
+	if name == "" {
+		return instance
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logger.FromContext(r.Context()).Warn("Неизвестный сохранённый часовой пояс", "timezone", name, "error", err)
+		return instance
+	}
+	return loc
+}
+
+// requestTimezone возвращает имя часового пояса, указанное в запросе
+func requestTimezone(r *http.Request) string {
+	if name := r.Header.Get(Header); name != "" {
+		return name
+	}
+	if name := r.URL.Query().Get("tz"); name != "" {
+		return name
+	}
+	if cookie, err := r.Cookie("tz"); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// FromContext возвращает часовой пояс запроса или локальный часовой пояс сервера
+func FromContext(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(contextKey{}).(*location); ok {
+		return loc.get()
+	}
+	return time.Local
+}
+
+// Today возвращает начало текущего дня в часовом поясе loc в виде полуночи UTC,
+// как и даты задач, разобранные по utils.DateFormat
+func Today(now time.Time, loc *time.Location) time.Time {
+	y, m, d := now.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// TodayString возвращает текущую дату в часовом поясе loc в формате utils.DateFormat
+func TodayString(now time.Time, loc *time.Location) string {
+	return Today(now, loc).Format(utils.DateFormat)
+}
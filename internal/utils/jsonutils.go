@@ -2,31 +2,33 @@ package utils
 
 import (
 	"encoding/json"
+	"final-project/internal/apierror"
 	moduls "final-project/internal/moduls"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
-// DecodeJSON декодирует JSON из тела запроса.
+// DecodeJSON декодирует JSON из тела запроса. Ошибки отправляются клиенту
+// в формате API.
 func DecodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	if r.Header.Get("Content-Type") != "application/json" {
-		SendError(w, "Неверный Content-Type", http.StatusBadRequest)
+		apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeBadRequest))
 		return fmt.Errorf("неверный Content-Type")
 	}
 	decoder := json.NewDecoder(r.Body)
 	defer r.Body.Close()
 	if err := decoder.Decode(v); err != nil {
-		SendError(w, "Ошибка декодирования JSON", http.StatusBadRequest)
+		apierror.Write(w, r, apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON).Wrap(err))
 		return fmt.Errorf("ошибка декодирования JSON: %w", err)
 	}
 	return nil
 }
 
-// SendJSON отправляет JSON-ответ клиенту.
+// SendJSON отправляет JSON-ответ клиенту с кодом status.
 func SendJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 
 	// Если data это слайс Scheduler, оборачиваем его в TaskResponse
 	if tasks, ok := data.([]moduls.Scheduler); ok {
@@ -36,16 +38,8 @@ func SendJSON(w http.ResponseWriter, status int, data interface{}) {
 		data = response
 	}
 
+	// Код ответа уже отправлен, поэтому ошибку можно только записать в журнал
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		SendError(w, "Ошибка кодирования JSON", http.StatusInternalServerError)
-		return
+		slog.Error("Ошибка кодирования JSON", "error", err)
 	}
 }
-
-// SendError отправляет ошибку клиенту.
-func SendError(w http.ResponseWriter, message string, statusCode int) {
-	log.Printf("Отправка ошибки: %s (код: %d)", message, statusCode)
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
-}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// requestV2 выполняет запрос к API v2 и возвращает статус и тело ответа
+func requestV2(t *testing.T, method, apipath string, values map[string]any) (int, map[string]any) {
+	var data []byte
+	if values != nil {
+		var err error
+		data, err = json.Marshal(values)
+		assert.NoError(t, err)
+	}
+
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var m map[string]any
+	if len(body) > 0 {
+		assert.NoError(t, json.Unmarshal(body, &m))
+	}
+	return resp.StatusCode, m
+}
+
+func TestAPIv2(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	today := time.Now().Format(`20060102`)
+	resp, m := requestAs(t, "", http.MethodPost, "api/v2/tasks", map[string]any{
+		"date":    today,
+		"title":   "Полить цветы",
+		"comment": "на балконе",
+		"repeat":  "d 3",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	// Идентификатор — число, а не строка
+	id, ok := m["id"].(float64)
+	assert.True(t, ok, "id должен быть числом: %v", m["id"])
+	path := fmt.Sprintf("api/v2/tasks/%d", int64(id))
+	assert.Equal(t, "/"+path, resp.Header.Get("Location"))
+
+	status, m := requestV2(t, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Полить цветы", m["title"])
+
+	// PATCH меняет только переданные поля
+	status, m = requestV2(t, http.MethodPatch, path, map[string]any{"comment": "в гостиной"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Полить цветы", m["title"])
+	assert.Equal(t, "в гостиной", m["comment"])
+	assert.Equal(t, "d 3", m["repeat"])
+
+	status, m = requestV2(t, http.MethodPost, path+"/complete", nil)
+	assert.Equal(t, http.StatusOK, status)
+	next := time.Now().AddDate(0, 0, 3).Format(`20060102`)
+	assert.Equal(t, next, m["date"])
+
+	status, _ = requestV2(t, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNoContent, status)
+	status, m = requestV2(t, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "task_not_found", m["code"])
+}
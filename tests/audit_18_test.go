@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	// Отдельный токен, чтобы отобрать только свои записи
+	token := fmt.Sprintf("audit-%d", time.Now().UnixNano())
+	now := time.Now()
+
+	resp, m := requestAs(t, token, http.MethodPost, "api/task", map[string]any{
+		"date":   now.Format(`20060102`),
+		"title":  "Полить цветы",
+		"repeat": "d 3",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	id := fmt.Sprint(m["id"])
+
+	resp, _ = requestAs(t, token, http.MethodPut, "api/task", map[string]any{
+		"id":     id,
+		"date":   now.Format(`20060102`),
+		"title":  "Полить цветы на балконе",
+		"repeat": "d 3",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = requestAs(t, token, http.MethodPost, "api/task/done?id="+id, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Записи идут от последней к первой и хранят состояния до и после изменения
+	resp, m = requestAs(t, token, http.MethodGet, "api/audit?task_id="+id, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	entries, _ := m["entries"].([]any)
+	if !assert.Len(t, entries, 3) {
+		return
+	}
+	var actions []string
+	for _, e := range entries {
+		entry := e.(map[string]any)
+		actions = append(actions, fmt.Sprint(entry["action"]))
+		assert.Equal(t, id, entry["task_id"])
+		assert.NotEmpty(t, entry["request_id"])
+		assert.Equal(t, entries[0].(map[string]any)["actor"], entry["actor"])
+	}
+	assert.Equal(t, []string{"done", "update", "create"}, actions)
+
+	create := entries[2].(map[string]any)
+	assert.Nil(t, create["before"])
+	assert.Equal(t, "Полить цветы", create["after"].(map[string]any)["title"])
+
+	update := entries[1].(map[string]any)
+	assert.Equal(t, "Полить цветы", update["before"].(map[string]any)["title"])
+	assert.Equal(t, "Полить цветы на балконе", update["after"].(map[string]any)["title"])
+
+	done := entries[0].(map[string]any)
+	assert.NotEqual(t, done["before"].(map[string]any)["date"], done["after"].(map[string]any)["date"])
+
+	// Токен не хранится в журнале
+	actor := fmt.Sprint(done["actor"])
+	assert.True(t, strings.HasPrefix(actor, "token:"), actor)
+	assert.NotContains(t, actor, token)
+
+	resp, m = requestAs(t, token, http.MethodGet, "api/audit?action=done&actor="+actor, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, m["entries"], 1)
+
+	// Выгрузка в CSV
+	req, err := http.NewRequest(http.MethodGet, getURL("api/audit?format=csv&task_id="+id), nil)
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", token)
+	csvResp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer csvResp.Body.Close()
+	assert.Equal(t, http.StatusOK, csvResp.StatusCode)
+	assert.True(t, strings.HasPrefix(csvResp.Header.Get("Content-Type"), "text/csv"))
+	body, err := io.ReadAll(csvResp.Body)
+	assert.NoError(t, err)
+	records, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 4) {
+		assert.Equal(t, "action", records[0][4])
+		assert.Equal(t, "done", records[1][4])
+	}
+
+	// Неверные параметры отклоняются
+	resp, m = requestAs(t, token, http.MethodGet, "api/audit?format=xml", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, "validation_failed", m["code"])
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"final-project/internal/logger"
+	"final-project/internal/moduls"
+)
+
+// captureBody пропускает тело запроса через запись тел и возвращает, что попадёт в журнал
+func captureBody(t *testing.T, cfg moduls.BodyCapture, contentType, body string) string {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/api/signin", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	capture := logger.NewBodyCapture(cfg).Start(r)
+	if !assert.NotNil(t, capture) {
+		return ""
+	}
+	_, err := io.ReadAll(r.Body)
+	assert.NoError(t, err)
+	for _, attr := range capture.Attrs(r, http.Header{}) {
+		if attr.Key == "request_body" {
+			return attr.Value.String()
+		}
+	}
+	return ""
+}
+
+func TestBodyCaptureMask(t *testing.T) {
+	cfg := moduls.BodyCapture{Enabled: true, Mask: []string{"pin"}}
+	tbl := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{"application/json", `{"password":"secret1","title":"a"}`,
+			`{"password":"[REDACTED]","title":"a"}`},
+		{"application/json; charset=utf-8", `{"title":"a", "Token": "abc", "pin": 1234}`,
+			`{"title":"a", "Token": "[REDACTED]", "pin": "[REDACTED]"}`},
+		{"application/x-www-form-urlencoded", "password=secret1",
+			"password=[REDACTED]"},
+		{"application/x-www-form-urlencoded", "login=user&password=p%40ss&remember=1",
+			"login=user&password=[REDACTED]&remember=1"},
+		{"application/x-www-form-urlencoded; charset=utf-8", "PIN=1234&token=&mypassword=x",
+			"PIN=[REDACTED]&token=[REDACTED]&mypassword=x"},
+	}
+	for _, v := range tbl {
+		assert.Equal(t, v.want, captureBody(t, cfg, v.contentType, v.body), v.body)
+	}
+
+	// Обрезанное значение формы тоже скрывается
+	cfg.MaxBytes = 12
+	assert.Equal(t, "password=[REDACTED]...[truncated 4 bytes]",
+		captureBody(t, cfg, "application/x-www-form-urlencoded", "password=secret1"))
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// requestAt выполняет запрос с подменой текущего времени через заголовок X-Debug-Now.
+// Сервер должен быть запущен с TODO_DEBUG_CLOCK=true.
+func requestAt(t *testing.T, now, apipath string, values map[string]any, method string) map[string]any {
+	var data []byte
+	if len(values) > 0 {
+		var err error
+		data, err = json.Marshal(values)
+		assert.NoError(t, err)
+	}
+
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Debug-Now", now)
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	return m
+}
+
+func TestDebugClock(t *testing.T) {
+	if !DebugClock {
+		return
+	}
+	db := openDB(t)
+	defer db.Close()
+
+	// Дата в прошлом заменяется "сегодняшней" датой по подменённым часам
+	m := requestAt(t, "20240126", "api/task", map[string]any{
+		"date":   "20240101",
+		"title":  "Полить цветы",
+		"repeat": "d 3",
+	}, http.MethodPost)
+	id := fmt.Sprint(m["id"])
+
+	var task Task
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, "20240126", task.Date)
+
+	want := []string{"20240129", "20240201", "20240204"}
+	for _, date := range want {
+		m = requestAt(t, "20240126", "api/task/done?id="+id, nil, http.MethodPost)
+		assert.Empty(t, m)
+		assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+		assert.Equal(t, date, task.Date)
+	}
+
+	m = requestAt(t, "20240126", "api/task?id="+id, nil, http.MethodDelete)
+	assert.Empty(t, m)
+
+	// Журнал аудита ведётся по тем же часам, поэтому сценарий воспроизводим
+	var times []string
+	assert.NoError(t, db.Select(&times, `SELECT time FROM audit_log WHERE task_id=?`, id))
+	assert.Len(t, times, 5)
+	for _, value := range times {
+		changed, err := time.Parse(time.RFC3339Nano, value)
+		if assert.NoError(t, err) {
+			// Полночь 26 января в часовом поясе запроса
+			assert.WithinDuration(t, time.Date(2024, 1, 26, 0, 0, 0, 0, time.UTC), changed, 14*time.Hour)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"final-project/internal/database"
+	"final-project/internal/tasks"
+)
+
+func TestQueryTimeout(t *testing.T) {
+	db := localDB(t)
+	// Тайм-аут истекает раньше, чем запрос доходит до базы данных
+	db.SetQueryTimeout(time.Nanosecond)
+	t.Cleanup(func() { db.SetQueryTimeout(database.DefaultQueryTimeout) })
+
+	w := httptest.NewRecorder()
+	tasks.TaskHandler(w, httptest.NewRequest(http.MethodGet, "/api/task?id=1", nil), db)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	var apiErr apiError
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+	assert.Equal(t, "timeout", apiErr.Code)
+
+	// С обычным тайм-аутом тот же запрос доходит до базы данных
+	db.SetQueryTimeout(database.DefaultQueryTimeout)
+	w = httptest.NewRecorder()
+	tasks.TaskHandler(w, httptest.NewRequest(http.MethodGet, "/api/task?id=1", nil), db)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
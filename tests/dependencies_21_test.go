@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencies(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	today := now.Format(`20060102`)
+	id := addTask(t, task{date: today, title: "Отправить отчёт"})
+
+	// Разовая задача блокирует, пока не выполнена, даже если её дата позже
+	blocker := addTask(t, task{date: now.AddDate(0, 0, 10).Format(`20060102`), title: "Собрать данные"})
+	resp, _ := requestAs(t, "", http.MethodPost, fmt.Sprintf("api/task/dependency?id=%s&depends_on=%s", id, blocker), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Повторяющаяся задача с датой позже зависимой уже выполнена для неё
+	repeating := addTask(t, task{date: now.AddDate(0, 0, 5).Format(`20060102`), title: "Планёрка", repeat: "d 7"})
+	resp, _ = requestAs(t, "", http.MethodPost, fmt.Sprintf("api/task/dependency?id=%s&depends_on=%s", id, repeating), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, m := requestAs(t, "", http.MethodPost, "api/task/done?id="+id, nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "task_blocked", m["code"])
+	blockedBy, _ := m["blocked_by"].([]any)
+	if assert.Len(t, blockedBy, 1) {
+		assert.Equal(t, blocker, blockedBy[0].(map[string]any)["id"])
+	}
+
+	resp, _ = requestAs(t, "", http.MethodPost, "api/task/done?id="+blocker, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Вместе с задачей удаляются и её зависимости
+	var deps int
+	assert.NoError(t, db.Get(&deps, `SELECT COUNT(*) FROM task_dependencies WHERE task_id = ? OR depends_on_id = ?`, blocker, blocker))
+	assert.Zero(t, deps)
+
+	resp, _ = requestAs(t, "", http.MethodPost, "api/task/done?id="+id, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NoError(t, db.Get(&deps, `SELECT COUNT(*) FROM task_dependencies WHERE task_id = ? OR depends_on_id = ?`, id, id))
+	assert.Zero(t, deps)
+}
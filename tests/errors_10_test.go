@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// apiError ответ сервера с ошибкой
+type apiError struct {
+	Error   string `json:"error"`
+	Code    string `json:"code"`
+	Details []struct {
+		Field string `json:"field"`
+		Code  string `json:"code"`
+	} `json:"details"`
+}
+
+// requestError выполняет запрос и возвращает статус и разобранную ошибку
+func requestError(t *testing.T, apipath, lang string, values map[string]any, method string) (int, apiError) {
+	var data []byte
+	if len(values) > 0 {
+		var err error
+		data, err = json.Marshal(values)
+		assert.NoError(t, err)
+	}
+
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if lang != "" {
+		req.Header.Set("Accept-Language", lang)
+	}
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var e apiError
+	assert.NoError(t, json.Unmarshal(body, &e))
+	return resp.StatusCode, e
+}
+
+func TestErrors(t *testing.T) {
+	// Ошибки всех полей возвращаются сразу
+	status, e := requestError(t, "api/task", "", map[string]any{
+		"date":  "20240192",
+		"title": "",
+	}, http.MethodPost)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "validation_failed", e.Code)
+	assert.NotEmpty(t, e.Error)
+	fields := map[string]string{}
+	for _, d := range e.Details {
+		fields[d.Field] = d.Code
+	}
+	assert.Equal(t, map[string]string{"date": "invalid_format", "title": "required"}, fields)
+
+	status, e = requestError(t, "api/task?id=99999999", "", nil, http.MethodGet)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "task_not_found", e.Code)
+
+	status, e = requestError(t, "api/task/done?id=99999999", "", nil, http.MethodPost)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "task_not_found", e.Code)
+
+	// Текст ошибки зависит от языка, код — нет
+	_, en := requestError(t, "api/task?id=99999999", "en", nil, http.MethodGet)
+	_, ru := requestError(t, "api/task?id=99999999", "ru-RU,ru;q=0.9,en;q=0.8", nil, http.MethodGet)
+	assert.Equal(t, en.Code, ru.Code)
+	assert.NotEqual(t, en.Error, ru.Error)
+}
+
+func TestSuccessStatus(t *testing.T) {
+	// Коды успешных ответов API v1 не изменились вместе с форматом ошибок
+	resp, m := requestAs(t, "", http.MethodPost, "api/task", map[string]any{
+		"date":  time.Now().Format(`20060102`),
+		"title": "Код ответа",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	if assert.NotEmpty(t, m["id"]) {
+		resp, _ = requestAs(t, "", http.MethodGet, fmt.Sprintf("api/task?id=%v", m["id"]), nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = requestAs(t, "", http.MethodDelete, fmt.Sprintf("api/task?id=%v", m["id"]), nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
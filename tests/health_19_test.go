@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	for _, apipath := range []string{"api/health/live", "api/health/ready"} {
+		resp, m := requestAs(t, "", http.MethodGet, apipath, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, apipath)
+		assert.Equal(t, "ok", m["status"], apipath)
+	}
+
+	resp, m := requestAs(t, "health", http.MethodGet, "api/health/details", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok", m["status"])
+
+	db, ok := m["database"].(map[string]any)
+	if assert.True(t, ok) {
+		assert.Equal(t, "ok", db["status"])
+		assert.Equal(t, db["schema_latest"], db["schema_version"])
+		assert.Contains(t, db, "latency_ms")
+		assert.Contains(t, db, "wal_bytes")
+	}
+
+	var names []any
+	caches, _ := m["caches"].([]any)
+	for _, c := range caches {
+		names = append(names, c.(map[string]any)["name"])
+	}
+	assert.Contains(t, names, "tasks")
+
+	workers, _ := m["workers"].([]any)
+	assert.NotEmpty(t, workers)
+	for _, w := range workers {
+		assert.Equal(t, true, w.(map[string]any)["running"])
+	}
+
+	build, ok := m["build"].(map[string]any)
+	if assert.True(t, ok) {
+		assert.NotEmpty(t, build["version"])
+		assert.NotEmpty(t, build["go_version"])
+	}
+}
+
+func TestHealthNotRateLimited(t *testing.T) {
+	if !RateLimit {
+		return
+	}
+	// Отдельный адрес (X-Real-IP), чтобы не расходовать лимит адреса других тестов
+	address := fmt.Sprintf("198.51.100.%d", time.Now().UnixNano()%250+1)
+	get := func(apipath string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, getURL(apipath), nil)
+		assert.NoError(t, err)
+		req.Header.Set("Authorization", "health-probe")
+		req.Header.Set("X-Real-IP", address)
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp
+	}
+
+	// Лимит чтения для адреса исчерпан
+	resp := get("api/nextdate?now=20240126&date=20240126&repeat=d+1")
+	for i := 0; i < 2000 && resp.StatusCode != http.StatusTooManyRequests; i++ {
+		resp = get("api/nextdate?now=20240126&date=20240126&repeat=d+1")
+	}
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// Проверки оркестратора всё равно отвечают
+	for _, apipath := range []string{"api/health/live", "api/health/ready"} {
+		resp = get(apipath)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, apipath)
+		assert.Empty(t, resp.Header.Get("RateLimit-Limit"), apipath)
+	}
+}
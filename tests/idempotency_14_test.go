@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// requestIdempotent выполняет POST-запрос с заголовком Idempotency-Key
+func requestIdempotent(t *testing.T, apipath, key string, values map[string]any) (int, http.Header, map[string]any) {
+	var data []byte
+	if values != nil {
+		var err error
+		data, err = json.Marshal(values)
+		assert.NoError(t, err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, getURL(apipath), bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var m map[string]any
+	if len(body) > 0 {
+		assert.NoError(t, json.Unmarshal(body, &m))
+	}
+	return resp.StatusCode, resp.Header, m
+}
+
+func TestIdempotency(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	before, err := count(db)
+	assert.NoError(t, err)
+
+	key := fmt.Sprintf("create-%d", time.Now().UnixNano())
+	values := map[string]any{
+		"date":  time.Now().Format(`20060102`),
+		"title": "Оплатить интернет",
+	}
+	status, header, first := requestIdempotent(t, "api/task", key, values)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, header.Get("Idempotent-Replayed"))
+
+	// Повтор с тем же ключом возвращает тот же ответ и не создаёт задачу
+	status, header, second := requestIdempotent(t, "api/task", key, values)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "true", header.Get("Idempotent-Replayed"))
+	assert.Equal(t, first, second)
+
+	after, err := count(db)
+	assert.NoError(t, err)
+	assert.Equal(t, before+1, after)
+
+	// Тот же ключ с другим телом — конфликт
+	values["title"] = "Оплатить телефон"
+	status, _, m := requestIdempotent(t, "api/task", key, values)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "idempotency_key_reused", m["code"])
+
+	// Повтор выполнения не удаляет задачу второй раз
+	id := fmt.Sprint(first["id"])
+	key = fmt.Sprintf("done-%d", time.Now().UnixNano())
+	status, _, _ = requestIdempotent(t, "api/task/done?id="+id, key, nil)
+	assert.Equal(t, http.StatusOK, status)
+	status, header, _ = requestIdempotent(t, "api/task/done?id="+id, key, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "true", header.Get("Idempotent-Replayed"))
+
+	// Ключ на другом адресе — другой запрос
+	status, _, m = requestIdempotent(t, "api/task/done?id=99999999", key, nil)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "idempotency_key_reused", m["code"])
+}
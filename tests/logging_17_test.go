@@ -0,0 +1,28 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	// Переданный клиентом X-Request-ID возвращается в ответе и попадает в журнал запроса
+	req, err := http.NewRequest(http.MethodGet, getURL("api/tasks"), nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-ID", "test-request-17")
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "test-request-17", resp.Header.Get("X-Request-ID"))
+
+	// Без заголовка идентификатор создаётся сервером
+	resp, err = http.Get(getURL("api/tasks"))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.NotEmpty(t, resp.Header.Get("X-Request-ID"))
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hasSeries проверяет, что в ответе /metrics есть ряд series
+func hasSeries(body, series string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, series+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMetrics(t *testing.T) {
+	id := addTask(t, task{
+		date:   time.Now().Format(`20060102`),
+		title:  "Проверить метрики",
+		repeat: "w 1",
+	})
+	_, err := getBody("api/tasks")
+	assert.NoError(t, err)
+	_, err = getBody("api/tasks")
+	assert.NoError(t, err)
+	// Нестандартный метод не создаёт отдельный ряд
+	resp, _ := requestAs(t, "metrics-test", "FOOBAR", "api/tasks", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	// Метрики отдаются на отдельном адресе без авторизации
+	// или на основном порту с токеном
+	metricsURL := getURL("metrics")
+	if MetricsAddr != "" {
+		metricsURL = "http://" + MetricsAddr + "/metrics"
+	}
+	req, err := http.NewRequest(http.MethodGet, metricsURL, nil)
+	assert.NoError(t, err)
+	if MetricsAddr == "" {
+		req.Header.Set("Authorization", "metrics-test")
+	}
+	resp, err = http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4"))
+	data, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	body := string(data)
+
+	// Маршрут — шаблон chi, а не путь запроса
+	assert.True(t, hasSeries(body, `http_requests_total{method="GET",route="/api/tasks",status="200"}`), body)
+	assert.True(t, hasSeries(body, `http_request_duration_seconds_bucket{method="POST",route="/api/task",status="200",le="+Inf"}`))
+	assert.True(t, hasSeries(body, `db_query_duration_seconds_count{method="Create"}`))
+	assert.True(t, hasSeries(body, `cache_requests_total{cache="tasks",result="hit"}`))
+	assert.True(t, hasSeries(body, `scheduler_tasks{repeat="w"}`))
+	assert.Contains(t, body, `method="OTHER"`)
+	assert.NotContains(t, body, `method="FOOBAR"`)
+
+	_, err = requestJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+
+	// С отдельным адресом метрик на основном порту их нет
+	if MetricsAddr != "" {
+		resp, _ := requestAs(t, "metrics-test", http.MethodGet, "metrics", nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+}
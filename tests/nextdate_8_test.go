@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// checkNextDate сверяет ответы /api/nextdate с таблицей; пустое want
+// означает, что правило должно быть отклонено
+func checkNextDate(t *testing.T, tbl []nextDate) {
+	for _, v := range tbl {
+		urlPath := fmt.Sprintf("api/nextdate?now=20240126&date=%s&repeat=%s",
+			url.QueryEscape(v.date), url.QueryEscape(v.repeat))
+		get, err := getBody(urlPath)
+		assert.NoError(t, err)
+		next := strings.TrimSpace(string(get))
+		_, err = time.Parse("20060102", next)
+		if err != nil && len(v.want) == 0 {
+			continue
+		}
+		assert.Equal(t, v.want, next, `{%q, %q, %q}`,
+			v.date, v.repeat, v.want)
+	}
+}
+
+func TestNextDateNthWeekday(t *testing.T) {
+	if !FullNextDate {
+		return
+	}
+	checkNextDate(t, []nextDate{
+		{"20240126", "mw", ""},
+		{"20240126", "mw 2", ""},
+		{"20240126", "mw 0-2", ""},
+		{"20240126", "mw 6-1", ""},
+		{"20240126", "mw -3-1", ""},
+		{"20240126", "mw 2-8", ""},
+		{"20240126", "mw 1-1 13", ""},
+		{"20240126", "mw 2-2", "20240213"},
+		{"20240101", "mw -1-5", "20240223"},
+		{"20240126", "mw -2-3", "20240221"},
+		{"20240126", "mw 1-1 3,6", "20240304"},
+		{"20240126", "mw 5-5", "20240329"},
+		{"20240126", "mw 1-1,3-5", "20240205"},
+		{"20240301", "mw 1-5", "20240301"},
+	})
+}
+
+func TestNextDateBusinessDays(t *testing.T) {
+	if !FullNextDate {
+		return
+	}
+	checkNextDate(t, []nextDate{
+		{"20240126", "b", ""},
+		{"20240126", "b 0", ""},
+		{"20240126", "b 401", ""},
+		{"20240126", "b x", ""},
+		{"20240126", "b 1", "20240129"},
+		{"20240127", "b 1", "20240129"},
+		{"20240122", "b 3", "20240130"},
+		{"20240119", "b 5", "20240202"},
+	})
+}
+
+func TestNextDateWeekInterval(t *testing.T) {
+	if !FullNextDate {
+		return
+	}
+	checkNextDate(t, []nextDate{
+		{"20240126", "w 1,3 /0", ""},
+		{"20240126", "w 1,3 /53", ""},
+		{"20240126", "w 1 /x", ""},
+		{"20240126", "w 1 2", ""},
+		{"20240126", "w /2", ""},
+		{"20240126", "w 1 /2 /3", ""},
+		{"20240126", "w 5 /1", "20240202"},
+		{"20240101", "w 1,6", "20240127"},
+		{"20240101", "w 2,7", "20240128"},
+		{"20240101", "w 1,3 /2", "20240129"},
+		{"20240108", "w 1,3 /2", "20240205"},
+		{"20240110", "w 1 /3", "20240129"},
+		{"20240205", "w 2 /2", "20240206"},
+		{"20231204", "w 5,7 /4", "20240202"},
+	})
+}
+
+func TestNextDateYearlyDates(t *testing.T) {
+	if !FullNextDate {
+		return
+	}
+	checkNextDate(t, []nextDate{
+		{"20240126", "y 0230", ""},
+		{"20240126", "y 1301", ""},
+		{"20240126", "y 315", ""},
+		{"20240126", "y feb28 mar1", ""},
+		{"20240126", "y 0315 0401", ""},
+		{"20240126", "y 0315,1201", "20240315"},
+		{"20240401", "y 0315,1201", "20241201"},
+		{"20240126", "y 0125", "20250125"},
+		{"20240126", "y 0229", "20240229"},
+		{"20240301", "y 0229", "20250301"},
+		{"20240301", "y 0229 feb28", "20250228"},
+		{"20240229", "y feb28", "20250228"},
+		{"20240229", "y mar1", "20250301"},
+		{"20250228", "y feb28", "20260228"},
+		{"20270228", "y feb28", "20280229"},
+		{"20270301", "y mar1", "20280229"},
+		{"20240115", "y feb28", "20250115"},
+	})
+}
+
+func TestNextDateExcept(t *testing.T) {
+	// Даты-исключения пропускаются
+	get, err := getBody("api/nextdate?now=20240126&date=20240126&repeat=d+1&except=20240127,20240128")
+	assert.NoError(t, err)
+	assert.Equal(t, "20240129", strings.TrimSpace(string(get)))
+
+	// Некорректная дата-исключение не игнорируется
+	status, e := requestError(t, "api/nextdate?now=20240126&date=20240126&repeat=d+1&except=20240127,2024-01-28", "", nil, http.MethodGet)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	if assert.Len(t, e.Details, 1) {
+		assert.Equal(t, "except", e.Details[0].Field)
+		assert.Equal(t, "invalid_format", e.Details[0].Code)
+	}
+}
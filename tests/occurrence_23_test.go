@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipAndSnooze(t *testing.T) {
+	start := time.Now().AddDate(0, 0, 3)
+	date := start.Format(`20060102`)
+	resp, m := requestAs(t, "", http.MethodPost, "api/task", map[string]any{
+		"date":   date,
+		"title":  "Полить цветы",
+		"repeat": "d 7",
+		"anchor": "20200101",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	id := fmt.Sprint(m["id"])
+
+	// Исходную дату задаёт только сервер
+	_, m = requestAs(t, "", http.MethodGet, "api/task?id="+id, nil)
+	assert.NotContains(t, m, "anchor")
+
+	// Пропуск переносит задачу на следующую дату
+	resp, m = requestAs(t, "", http.MethodPost, "api/task/skip?id="+id, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	date = start.AddDate(0, 0, 7).Format(`20060102`)
+	assert.Equal(t, date, m["date"])
+
+	// Отложенное повторение запоминает исходную дату
+	resp, m = requestAs(t, "", http.MethodPost, "api/task/snooze?id="+id+"&days=2", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, start.AddDate(0, 0, 9).Format(`20060102`), m["date"])
+	assert.Equal(t, date, m["anchor"])
+
+	// Правка без смены даты и правила сохраняет исходную дату, а переданная
+	// клиентом не учитывается
+	resp, _ = requestAs(t, "", http.MethodPut, "api/task", map[string]any{
+		"id":     id,
+		"date":   m["date"],
+		"title":  "Полить цветы на балконе",
+		"repeat": "d 7",
+		"anchor": "20200101",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, m = requestAs(t, "", http.MethodGet, "api/task?id="+id, nil)
+	assert.Equal(t, date, m["anchor"])
+
+	// Следующая дата считается от исходной, серия не сдвигается
+	resp, m = requestAs(t, "", http.MethodPost, "api/task/skip?id="+id, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, start.AddDate(0, 0, 14).Format(`20060102`), m["date"])
+	assert.NotContains(t, m, "anchor")
+
+	for _, v := range []struct {
+		query string
+		field string
+		code  string
+	}{
+		{"", "days", "required"},
+		{"&days=0", "days", "out_of_range"},
+		{"&days=x", "days", "invalid_format"},
+		{"&until=2024", "until", "invalid_format"},
+		{"&until=20000101", "until", "out_of_range"},
+		{"&days=1&until=20990101", "days", "conflict"},
+	} {
+		status, e := requestError(t, "api/task/snooze?id="+id+v.query, "", nil, http.MethodPost)
+		assert.Equal(t, http.StatusUnprocessableEntity, status, v.query)
+		fields := map[string]string{}
+		for _, d := range e.Details {
+			fields[d.Field] = d.Code
+		}
+		assert.Equal(t, v.code, fields[v.field], v.query)
+	}
+
+	// Пропустить можно только повторяющуюся задачу
+	resp, m = requestAs(t, "", http.MethodPost, "api/task", map[string]any{
+		"date":  date,
+		"title": "Купить лейку",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	status, e := requestError(t, "api/task/skip?id="+fmt.Sprint(m["id"]), "", nil, http.MethodPost)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "task_not_recurring", e.Code)
+}
+
+func TestDoneInAdvance(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	// Понедельник не раньше чем через неделю
+	date := time.Now().AddDate(0, 0, 7)
+	for date.Weekday() != time.Monday {
+		date = date.AddDate(0, 0, 1)
+	}
+	id := addTask(t, task{
+		date:   date.Format(`20060102`),
+		title:  "Выполнить заранее",
+		repeat: "w 1",
+	})
+
+	// Выполненная заранее задача переносится на следующее повторение
+	// после своей даты, а не остаётся на ней
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, date.AddDate(0, 0, 7).Format(`20060102`), task.Date)
+}
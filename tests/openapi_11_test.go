@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPI(t *testing.T) {
+	body, err := getBody("api/openapi.json")
+	assert.NoError(t, err)
+
+	var doc struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &doc))
+	assert.Regexp(t, `^3\.`, doc.OpenAPI)
+
+	for path, methods := range map[string][]string{
+		"/api/task":      {"get", "post", "put", "delete"},
+		"/api/task/done": {"post"},
+		"/api/tasks":     {"get"},
+		"/api/nextdate":  {"get"},
+	} {
+		for _, method := range methods {
+			assert.Contains(t, doc.Paths[path], method, "нет описания %s %s", method, path)
+		}
+	}
+	for _, name := range []string{"Scheduler", "SchedulerList", "Errors"} {
+		assert.Contains(t, doc.Components.Schemas, name)
+	}
+
+	// Запрос с неверными типами отклоняется до обработчика
+	status, e := requestError(t, "api/task", "", map[string]any{
+		"date":  "20240126",
+		"title": 42,
+		"count": "много",
+	}, http.MethodPost)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "validation_failed", e.Code)
+	fields := map[string]string{}
+	for _, d := range e.Details {
+		fields[d.Field] = d.Code
+	}
+	assert.Equal(t, map[string]string{"count": "invalid_format", "title": "invalid_format"}, fields)
+
+	// Слишком большое тело отклоняется целиком, а не как ошибка поля
+	status, e = requestError(t, "api/task", "", map[string]any{
+		"date":    "20240126",
+		"title":   "Большое тело",
+		"comment": strings.Repeat("x", 1<<20),
+	}, http.MethodPost)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	assert.Equal(t, "request_too_large", e.Code)
+	assert.Empty(t, e.Details)
+
+	// Страница документации не загружает сторонние скрипты
+	resp, err := http.Get(getURL("api/docs"))
+	if assert.NoError(t, err) {
+		page, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Security-Policy"), "default-src 'none'")
+		assert.NotRegexp(t, `<script[^>]+src=`, string(page))
+	}
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatchTask(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	date := time.Now().AddDate(0, 0, 2).Format(`20060102`)
+	id := addTask(t, task{
+		date:    date,
+		title:   "Купить молоко",
+		comment: "2 литра",
+		repeat:  "d 7",
+	})
+
+	// Меняется только комментарий, дата и повтор остаются прежними
+	status, m := requestV2(t, http.MethodPatch, "api/task?id="+id, map[string]any{"comment": "1 литр"})
+	assert.Equal(t, http.StatusOK, status)
+
+	var task Task
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, "1 литр", task.Comment)
+	assert.Equal(t, date, task.Date)
+	assert.Equal(t, "Купить молоко", task.Title)
+	assert.Equal(t, "d 7", task.Repeat)
+	assert.Equal(t, id, m["id"])
+
+	// Правило повтора проверяется в задаче после применения патча
+	status, m = requestV2(t, http.MethodPatch, "api/task?id="+id, map[string]any{"repeat": "ooops"})
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "validation_failed", m["code"])
+
+	// null сбрасывает поле
+	status, _ = requestV2(t, http.MethodPatch, "api/task?id="+id, map[string]any{"repeat": nil})
+	assert.Equal(t, http.StatusOK, status)
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, "", task.Repeat)
+
+	status, _ = requestV2(t, http.MethodPatch, "api/task?id=99999999", map[string]any{"title": "x"})
+	assert.Equal(t, http.StatusNotFound, status)
+
+	_, err := db.Exec(`DELETE FROM scheduler WHERE id = ?`, id)
+	assert.NoError(t, err)
+}
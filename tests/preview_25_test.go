@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextDatePreview(t *testing.T) {
+	tbl := []struct {
+		query       string
+		dates       []any
+		description string
+		ended       bool
+	}{
+		{"repeat=d+1&count=3", []any{"20240127", "20240128", "20240129"}, "every day", false},
+		{"repeat=d+1&count=10", []any{"20240127", "20240128", "20240129", "20240130", "20240131",
+			"20240201", "20240202", "20240203", "20240204", "20240205"}, "every day", false},
+		// occurrences включает текущее повторение, как count в /api/nextdate
+		{"repeat=d+1&occurrences=3", []any{"20240127", "20240128"}, "every day", true},
+		{"repeat=d+1&until=20240129", []any{"20240127", "20240128", "20240129"}, "every day", true},
+		{"repeat=d+1&count=3&except=20240128", []any{"20240127", "20240129", "20240130"}, "every day", false},
+		{"repeat=w+1,3&count=3", []any{"20240129", "20240131", "20240205"}, "every Monday and Wednesday", false},
+		{"repeat=m+2,15+3,6&count=3", []any{"20240302", "20240315", "20240602"}, "every 2nd and 15th of March and June", false},
+		{"repeat=mw+-1-5&count=2", []any{"20240223", "20240329"}, "every month on the last Friday", false},
+		{"repeat=y&count=2", []any{"20250126", "20260126"}, "every year on the same date", false},
+	}
+	for _, v := range tbl {
+		resp, m := requestAs(t, "", http.MethodGet, "api/nextdate/preview?now=20240126&date=20240126&"+v.query, nil)
+		if !assert.Equal(t, http.StatusOK, resp.StatusCode, v.query) {
+			continue
+		}
+		assert.Equal(t, v.dates, m["dates"], v.query)
+		assert.Equal(t, v.description, m["description"], v.query)
+		assert.Equal(t, v.ended, m["ended"] == true, v.query)
+	}
+
+	for _, v := range []struct {
+		query string
+		field string
+		code  string
+	}{
+		{"repeat=d+1&count=0", "count", "out_of_range"},
+		{"repeat=d+1&count=101", "count", "out_of_range"},
+		{"repeat=d+1&occurrences=-1", "occurrences", "out_of_range"},
+		{"repeat=" + url.QueryEscape("m 40"), "repeat", "invalid_format"},
+		{"repeat=d+1&except=2024", "except", "invalid_format"},
+	} {
+		status, e := requestError(t, "api/nextdate/preview?now=20240126&"+v.query, "", nil, http.MethodGet)
+		assert.Equal(t, http.StatusUnprocessableEntity, status, v.query)
+		if assert.NotEmpty(t, e.Details, v.query) {
+			assert.Equal(t, v.field, e.Details[0].Field, v.query)
+			assert.Equal(t, v.code, e.Details[0].Code, v.query)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuickAdd(t *testing.T) {
+	tbl := []struct {
+		text   string
+		title  string
+		repeat string
+		date   string
+	}{
+		{"Pay rent every month on the 1st", "Pay rent", "m 1", ""},
+		{"Pay rent on the 1st every month", "Pay rent", "m 1", ""},
+		{"Pay rent on the 15th of each month", "Pay rent", "m 15", ""},
+		{"Invoices monthly on the 1st and 15th", "Invoices", "m 1,15", ""},
+		{"Backup every month on the last day", "Backup", "m -1", ""},
+		{"Standup every weekday", "Standup", "w 1,2,3,4,5", ""},
+		{"Hike every weekend", "Hike", "w 6,7", ""},
+		{"Water plants every 3 days", "Water plants", "d 3", ""},
+		{"Stretch daily", "Stretch", "d 1", ""},
+		{"Report every 2 business days", "Report", "b 2", ""},
+		{"Gym every monday and wednesday", "Gym", "w 1,3", ""},
+		{"Payroll every other friday", "Payroll", "w 5 /2", ""},
+		{"Team sync every 2 weeks on tue and thu", "Team sync", "w 2,4 /2", ""},
+		{"Book club every 2nd tuesday", "Book club", "mw 2-2", ""},
+		{"Review every last friday", "Review", "mw -1-5", ""},
+		{"Taxes every year on april 15", "Taxes", "y 0415", ""},
+		{"Dentist on 2030-03-15", "Dentist", "", "20300315"},
+		{"Renew passport on 15.03.2030", "Renew passport", "", "20300315"},
+		{"Anniversary yearly on 2030-06-01", "Anniversary", "y", "20300601"},
+		{"Fix bug #123 tomorrow", "Fix bug #123", "", ""},
+	}
+	// Отдельный токен, чтобы не расходовать лимит частоты запросов других тестов
+	token := fmt.Sprintf("quickadd-%d", time.Now().UnixNano())
+	for _, v := range tbl {
+		resp, m := requestAs(t, token, http.MethodPost, "api/task/quick", map[string]any{"text": v.text})
+		if !assert.Equal(t, http.StatusOK, resp.StatusCode, v.text) {
+			continue
+		}
+		task, _ := m["task"].(map[string]any)
+		assert.Equal(t, v.title, task["title"], v.text)
+		assert.Equal(t, v.repeat, task["repeat"], v.text)
+		if v.date != "" {
+			assert.Equal(t, v.date, task["date"], v.text)
+		}
+		assert.NotContains(t, m, "id", v.text)
+	}
+
+	// Теги и приоритет не хранятся, поэтому фраза с ними отклоняется
+	for text, want := range map[string]map[string]string{
+		"Pay rent every month on the 1st #finance !high": {"tags": "unsupported", "priority": "unsupported"},
+		"Call the bank !urgent":                          {"priority": "unsupported"},
+		"every day":                                      {"text": "invalid_value"},
+	} {
+		status, e := requestError(t, "api/task/quick", "", map[string]any{"text": text}, http.MethodPost)
+		assert.Equal(t, http.StatusUnprocessableEntity, status, text)
+		fields := map[string]string{}
+		for _, d := range e.Details {
+			fields[d.Field] = d.Code
+		}
+		assert.Equal(t, want, fields, text)
+	}
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// requestAs выполняет запрос от имени клиента с токеном token
+func requestAs(t *testing.T, token, method, apipath string, values map[string]any) (*http.Response, map[string]any) {
+	var data []byte
+	if values != nil {
+		var err error
+		data, err = json.Marshal(values)
+		assert.NoError(t, err)
+	}
+
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", token)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	// nextdate отвечает текстом, ошибки — всегда JSON
+	var m map[string]any
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		assert.NoError(t, json.Unmarshal(body, &m))
+	}
+	return resp, m
+}
+
+func TestRateLimit(t *testing.T) {
+	if !RateLimit {
+		return
+	}
+	// Отдельный токен, чтобы не расходовать лимит других тестов
+	token := fmt.Sprintf("ratelimit-%d", time.Now().UnixNano())
+	apipath := "api/nextdate?now=20240126&date=20240126&repeat=d+1"
+
+	resp, _ := requestAs(t, token, http.MethodGet, apipath, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	limit, err := strconv.Atoi(resp.Header.Get("RateLimit-Limit"))
+	assert.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(limit-1), resp.Header.Get("RateLimit-Remaining"))
+
+	// Серия запросов на чтение упирается в лимит
+	var m map[string]any
+	for i := 0; i < 10*limit && resp.StatusCode != http.StatusTooManyRequests; i++ {
+		resp, m = requestAs(t, token, http.MethodGet, apipath, nil)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "rate_limited", m["code"])
+	assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+	retry, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, retry, 1)
+
+	// Запросы на запись учитываются отдельно
+	resp, _ = requestAs(t, token, http.MethodPost, "api/task", map[string]any{"title": ""})
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	// Другой клиент не ограничен
+	resp, _ = requestAs(t, token+"-other", http.MethodGet, apipath, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestRateLimitRotatingTokens(t *testing.T) {
+	if !RateLimit {
+		return
+	}
+	// Отдельный адрес (X-Real-IP), чтобы не расходовать лимит адреса других тестов
+	address := fmt.Sprintf("203.0.113.%d", time.Now().UnixNano()%250+1)
+	request := func(token string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, getURL("api/task"), strings.NewReader(`{"title":""}`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", token)
+		req.Header.Set("X-Real-IP", address)
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp
+	}
+
+	// Новый токен на каждом запросе не даёт новой полной корзины
+	resp := request(fmt.Sprintf("rotate-%d", time.Now().UnixNano()))
+	limit, err := strconv.Atoi(resp.Header.Get("RateLimit-Limit"))
+	assert.NoError(t, err)
+	for i := 0; i < 20*limit && resp.StatusCode != http.StatusTooManyRequests; i++ {
+		resp = request(fmt.Sprintf("rotate-%d-%d", time.Now().UnixNano(), i))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+}
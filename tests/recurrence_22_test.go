@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutKeepsEndConditions(t *testing.T) {
+	now := time.Now()
+	until := now.AddDate(0, 1, 0).Format(`20060102`)
+	resp, m := requestAs(t, "", http.MethodPost, "api/task", map[string]any{
+		"date":   now.Format(`20060102`),
+		"title":  "Пить витамины",
+		"repeat": "d 1",
+		"until":  until,
+		"count":  5,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	id := fmt.Sprint(m["id"])
+
+	// Правка из веб-интерфейса: until и count в теле нет
+	resp, _ = requestAs(t, "", http.MethodPut, "api/task", map[string]any{
+		"id":      id,
+		"date":    now.Format(`20060102`),
+		"title":   "Пить витамины после завтрака",
+		"comment": "",
+		"repeat":  "d 1",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, m = requestAs(t, "", http.MethodGet, "api/task?id="+id, nil)
+	assert.Equal(t, "Пить витамины после завтрака", m["title"])
+	assert.Equal(t, until, m["until"])
+	assert.EqualValues(t, 5, m["count"])
+
+	// Переданные явно пустые значения снимают условия
+	resp, _ = requestAs(t, "", http.MethodPut, "api/task", map[string]any{
+		"id":     id,
+		"date":   now.Format(`20060102`),
+		"title":  "Пить витамины после завтрака",
+		"repeat": "d 1",
+		"until":  "",
+		"count":  0,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, m = requestAs(t, "", http.MethodGet, "api/task?id="+id, nil)
+	assert.NotContains(t, m, "until")
+	assert.NotContains(t, m, "count")
+
+	// Без правила повтора условия окончания не сохраняются
+	resp, _ = requestAs(t, "", http.MethodPut, "api/task", map[string]any{
+		"id":     id,
+		"date":   now.Format(`20060102`),
+		"title":  "Пить витамины после завтрака",
+		"repeat": "d 1",
+		"count":  3,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = requestAs(t, "", http.MethodPut, "api/task", map[string]any{
+		"id":    id,
+		"date":  now.Format(`20060102`),
+		"title": "Пить витамины после завтрака",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, m = requestAs(t, "", http.MethodGet, "api/task?id="+id, nil)
+	assert.NotContains(t, m, "count")
+}
@@ -6,4 +6,13 @@ var (
 	FullNextDate = true
 	Search       = true
 	Token        = ``
+	DebugClock   = false
+	RateLimit    = true
+	// MetricsAddr адрес метрик, если сервер запущен с TODO_METRICS_ADDR
+	MetricsAddr = ``
+	// TLS сервер запущен по HTTPS (TODO_TLS_SELF_SIGNED=true TODO_HSTS=true)
+	// с перенаправлением с порта TLSRedirectPort; проверяется отдельным
+	// запуском go test -run TestTLS, остальные тесты обращаются по HTTP
+	TLS             = false
+	TLSRedirectPort = 7080
 )
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShutdown запускает отдельный экземпляр сервера и проверяет, что по SIGTERM
+// он перестаёт принимать соединения, но отвечает на уже начатый запрос
+func TestShutdown(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "server")
+	if out, err := exec.Command("go", "build", "-o", bin, "../cmd/server").CombinedOutput(); err != nil {
+		t.Fatalf("ошибка сборки сервера: %v\n%s", err, out)
+	}
+	// Конфигурация читается из .env в рабочем каталоге
+	if err := os.WriteFile(filepath.Join(dir, ".env"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	addr := fmt.Sprintf("localhost:%d", port)
+
+	var output bytes.Buffer
+	cmd := exec.Command(bin)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"TODO_DBFILE="+filepath.Join(dir, "scheduler.db"),
+		fmt.Sprintf("TODO_PORT=%d", port),
+		"TODO_METRICS_ADDR=",
+		"TODO_SHUTDOWN_TIMEOUT=10s",
+	)
+	cmd.Stdout, cmd.Stderr = &output, &output
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		if t.Failed() {
+			t.Log(output.String())
+		}
+	}()
+
+	// Ожидание запуска
+	url := fmt.Sprintf("http://%s/api/health/live", addr)
+	for start := time.Now(); ; time.Sleep(50 * time.Millisecond) {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("сервер не запустился: %v", err)
+		}
+	}
+
+	// Запрос начат: обработчик ждёт оставшуюся часть тела
+	body := `{"date":"20240101","title":"Остановка сервера"}`
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, err = fmt.Fprintf(conn, "POST /api/task HTTP/1.1\r\nHost: %s\r\nAuthorization: shutdown-test\r\n"+
+		"Content-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", addr, len(body), body[:10])
+	assert.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	assert.NoError(t, cmd.Process.Signal(syscall.SIGTERM))
+	time.Sleep(300 * time.Millisecond)
+
+	// Новые соединения не принимаются
+	_, err = net.DialTimeout("tcp", addr, time.Second)
+	assert.Error(t, err)
+
+	// Начатый запрос завершается
+	_, err = fmt.Fprint(conn, body[10:])
+	assert.NoError(t, err)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if assert.NoError(t, err) {
+		var task map[string]any
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&task))
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEmpty(t, task["id"])
+	}
+
+	// Сервер останавливается без ошибки
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Error("сервер не остановился")
+	}
+}
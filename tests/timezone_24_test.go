@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Часовые пояса по разные стороны линии перемены дат: «сегодня» в них
+// различается всегда
+const (
+	eastZone = "Pacific/Kiritimati"
+	westZone = "Pacific/Pago_Pago"
+)
+
+// tomorrowIn возвращает завтрашнюю дату в часовом поясе name
+func tomorrowIn(t *testing.T, name string) string {
+	loc, err := time.LoadLocation(name)
+	assert.NoError(t, err)
+	return time.Now().In(loc).AddDate(0, 0, 1).Format(`20060102`)
+}
+
+// firstPreviewDate возвращает первую дату предпросмотра ежедневного повтора
+// без параметров now и date, то есть начиная с сегодняшнего дня
+func firstPreviewDate(t *testing.T, token, query string) string {
+	resp, m := requestAs(t, token, http.MethodGet, "api/nextdate/preview?repeat=d+1"+query, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	dates, _ := m["dates"].([]any)
+	if !assert.NotEmpty(t, dates) {
+		return ""
+	}
+	return fmt.Sprint(dates[0])
+}
+
+func TestTimezone(t *testing.T) {
+	// Без now следующая дата считается от сегодняшней даты часового пояса запроса
+	for _, zone := range []string{eastZone, westZone} {
+		body, err := getBody("api/nextdate?date=20240101&repeat=d+1&tz=" + zone)
+		assert.NoError(t, err)
+		assert.Equal(t, tomorrowIn(t, zone), strings.TrimSpace(string(body)), zone)
+	}
+
+	// Сохранённый часовой пояс действует для своего токена
+	token := fmt.Sprintf("timezone-%d", time.Now().UnixNano())
+	resp, m := requestAs(t, token, http.MethodPut, "api/settings", map[string]any{"timezone": eastZone})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, eastZone, m["timezone"])
+
+	_, m = requestAs(t, token, http.MethodGet, "api/settings", nil)
+	assert.Equal(t, eastZone, m["timezone"])
+	assert.Equal(t, eastZone, m["effective"])
+	assert.Equal(t, tomorrowIn(t, eastZone), firstPreviewDate(t, token, ""))
+
+	_, m = requestAs(t, token+"-other", http.MethodGet, "api/settings", nil)
+	assert.Equal(t, "", m["timezone"])
+
+	// Часовой пояс запроса важнее сохранённого
+	assert.Equal(t, tomorrowIn(t, westZone), firstPreviewDate(t, token, "&tz="+westZone))
+
+	resp, m = requestAs(t, token, http.MethodPut, "api/settings", map[string]any{"timezone": "Mars/Olympus"})
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, "validation_failed", m["code"])
+
+	// Пустая строка удаляет настройку
+	resp, _ = requestAs(t, token, http.MethodPut, "api/settings", map[string]any{"timezone": ""})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, m = requestAs(t, token, http.MethodGet, "api/settings", nil)
+	assert.Equal(t, "", m["timezone"])
+
+	// Без токена настроек нет
+	resp, _ = requestAs(t, "", http.MethodGet, "api/settings", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestOverdue(t *testing.T) {
+	// Задача на сегодня в западном часовом поясе
+	west, err := time.LoadLocation(westZone)
+	assert.NoError(t, err)
+	resp, m := requestAs(t, "", http.MethodPost, "api/task?tz="+westZone, map[string]any{
+		"date":  time.Now().In(west).Format(`20060102`),
+		"title": "Позвонить в Паго-Паго",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	id := fmt.Sprint(m["id"])
+	defer requestAs(t, "", http.MethodDelete, "api/task?id="+id, nil)
+
+	overdue := func(zone string) bool {
+		resp, m := requestAs(t, "", http.MethodGet, "api/tasks?overdue=true&tz="+zone, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		tasks, _ := m["tasks"].([]any)
+		for _, task := range tasks {
+			if fmt.Sprint(task.(map[string]any)["id"]) == id {
+				return true
+			}
+		}
+		return false
+	}
+	// В восточном часовом поясе этот день уже прошёл
+	assert.False(t, overdue(westZone))
+	assert.True(t, overdue(eastZone))
+}
+
+func TestTimezoneStatic(t *testing.T) {
+	get := func(path string) int {
+		req, err := http.NewRequest(http.MethodGet, getURL(path), nil)
+		assert.NoError(t, err)
+		req.AddCookie(&http.Cookie{Name: "tz", Value: "Not/AZone"})
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	// Часовой пояс проверяется только в запросах к API
+	assert.Equal(t, http.StatusOK, get(""))
+	assert.Equal(t, http.StatusUnprocessableEntity, get("api/nextdate?date=20240101&repeat=d+1"))
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLS(t *testing.T) {
+	if !TLS {
+		return
+	}
+	httpsURL := strings.Replace(getURL("api/health/live"), "http://", "https://", 1)
+
+	// Сертификат самоподписанный, поэтому не проверяется
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(httpsURL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	if assert.NotNil(t, resp.TLS) {
+		assert.GreaterOrEqual(t, resp.TLS.Version, uint16(tls.VersionTLS12))
+	}
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Strict-Transport-Security"), "max-age="))
+
+	// Запросы по HTTP перенаправляются на HTTPS с сохранением пути и метода
+	redirectURL := fmt.Sprintf("http://localhost:%d/api/task?id=1", TLSRedirectPort)
+	for method, status := range map[string]int{
+		http.MethodGet:  http.StatusMovedPermanently,
+		http.MethodPost: http.StatusPermanentRedirect,
+	} {
+		req, err := http.NewRequest(method, redirectURL, nil)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		resp, err := client.Do(req)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		resp.Body.Close()
+		assert.Equal(t, status, resp.StatusCode, method)
+		assert.Equal(t, fmt.Sprintf("https://localhost:%d/api/task?id=1", Port), resp.Header.Get("Location"), method)
+		assert.Empty(t, resp.Header.Get("Strict-Transport-Security"), method)
+	}
+}
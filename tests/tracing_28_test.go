@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"final-project/internal/auth"
+	"final-project/internal/database"
+	"final-project/internal/moduls"
+	"final-project/internal/tasks"
+	"final-project/internal/tracing"
+)
+
+var (
+	localOnce sync.Once
+	localDir  string
+)
+
+// localDB открывает базу данных во временном каталоге для тестов, которые
+// вызывают обработчики в процессе теста, а не через запущенный сервер.
+// База одна на весь запуск: database.InitDatabase создаёт её один раз.
+func localDB(t *testing.T) *database.DB {
+	t.Helper()
+	localOnce.Do(func() {
+		var err error
+		localDir, err = os.MkdirTemp("", "scheduler-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Setenv("TODO_DBFILE", filepath.Join(localDir, "scheduler.db"))
+	db := database.InitDatabase(context.Background())
+	if err := database.TestDatabaseConnection(db.DB); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTracing(t *testing.T) {
+	db := localDB(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		provider.Shutdown(context.Background())
+	})
+	_, err := tracing.Setup(context.Background(), moduls.Tracing{})
+	assert.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(auth.LoggingMiddleware(moduls.BodyCapture{}))
+	r.Get("/api/task", func(w http.ResponseWriter, r *http.Request) {
+		tasks.TaskHandler(w, r, db)
+	})
+
+	// Запрос продолжает трассу вызывающего сервиса из traceparent
+	req := httptest.NewRequest(http.MethodGet, "/api/task?id=1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	server, handler, query := spans["GET /api/task"], spans["tasks.TaskHandler"], spans["DB.GetpoID"]
+	if !assert.NotNil(t, server, "span запроса") ||
+		!assert.NotNil(t, handler, "span обработчика") ||
+		!assert.NotNil(t, query, "span базы данных") {
+		return
+	}
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, server.SpanContext().SpanID(), handler.Parent().SpanID())
+	assert.Equal(t, handler.SpanContext().SpanID(), query.Parent().SpanID())
+	assert.Equal(t, trace.SpanKindClient, query.SpanKind())
+
+	attrs := map[string]string{}
+	for _, attr := range server.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	assert.Equal(t, "/api/task", attrs["http.route"])
+	assert.Equal(t, "404", attrs["http.response.status_code"])
+	attrs = map[string]string{}
+	for _, attr := range query.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	assert.Equal(t, "sqlite", attrs["db.system"])
+	assert.Contains(t, attrs["db.query.text"], "WHERE s.id = ?")
+}